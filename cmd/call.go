@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gitlab.com/isbm/teabox/teaboxlib"
+	"gitlab.com/isbm/teabox/teaboxlib/teaboxclient"
+)
+
+// Exit codes of the "call" command
+const (
+	CALL_OK     = 0 // All calls were done
+	CALL_FAILED = 1 // At least one call could not be done
+	CALL_ERROR  = 2 // Wrong usage, or the socket is not reachable
+)
+
+// call sends the API calls in the simple syntax to the socket of the module and prints their results.
+// Calls are taken from the arguments, or from STDIN, one per line, if there are none or it is "-".
+// Returns an exit code, one of CALL_*.
+func call(appname string, args []string) int {
+	flags := flag.NewFlagSet("call", flag.ContinueOnError)
+	socket := flags.String("s", os.Getenv(teaboxlib.ENV_SOCKET), "Unix socket of the module")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s call [-s SOCKET] [CALL...|-]\n\n", appname)
+		fmt.Fprintln(flags.Output(), "Sends API calls, e.g. \"logger.status::Hello\", and prints their results.")
+		fmt.Fprintf(flags.Output(), "Calls are read from STDIN, one per line, if there are none or it is \"-\".\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return CALL_ERROR
+	}
+	if *socket == "" {
+		fmt.Fprintf(os.Stderr, "Error: no socket, use -s or %s environment variable\n", teaboxlib.ENV_SOCKET)
+		return CALL_ERROR
+	}
+
+	calls := flags.Args()
+	if len(calls) == 0 || (len(calls) == 1 && calls[0] == "-") {
+		var err error
+		if calls, err = readCalls(os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+			return CALL_ERROR
+		}
+	}
+
+	client := teaboxclient.NewTeaboxClientAt(*socket, os.Getenv(teaboxlib.ENV_TOKEN))
+	defer client.Close()
+
+	ret := CALL_OK
+	for _, data := range calls {
+		call := teaboxlib.NewTeaboxAPICall([]byte(data))
+		if err := call.GetError(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %s\n", data, err.Error())
+			ret = CALL_FAILED
+			continue
+		}
+
+		result, err := client.Call(call.GetClass(), call.GetKey(), getCallValue(call))
+		var callErr *teaboxclient.TeaboxCallError
+		if errors.As(err, &callErr) {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+			ret = CALL_FAILED
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+			return CALL_ERROR // Nothing else will get through either
+		} else if result != "" {
+			fmt.Println(result)
+		}
+	}
+
+	return ret
+}
+
+// Read the calls, one per line, skipping the empty ones
+func readCalls(r io.Reader) ([]string, error) {
+	calls := []string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0x10000), teaboxlib.API_MESSAGE_MAX_SIZE)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			calls = append(calls, line)
+		}
+	}
+
+	return calls, scanner.Err()
+}
+
+// Get the value of a call in the simple syntax, so the client sends it with the same type
+func getCallValue(call *teaboxlib.TeaboxAPICall) interface{} {
+	switch call.GetType() {
+	case "int":
+		return call.GetInt()
+	case "bool":
+		return call.GetBool()
+	case "json":
+		return json.RawMessage(strings.TrimSpace(call.GetValue().(string)))
+	}
+
+	if call.GetString() == "" {
+		return nil
+	}
+	return call.GetString()
+}
@@ -13,21 +13,29 @@ import (
 var VERSION = "0.3"
 
 func main() {
+	appname := path.Base(os.Args[0])
+
+	// Non-interactive subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate":
+			os.Exit(validate(appname))
+		case "call":
+			os.Exit(call(appname, os.Args[2:]))
+		}
+	}
+
 	if os.Getenv("TERM") != "xterm-256color" {
 		fmt.Println("Terminal should work in 256 color mode.")
 		os.Exit(1)
 	}
 
-	appname := path.Base(os.Args[0])
-
 	conf, err := teaboxlib.NewTeaConf(appname)
 	if err != nil {
 		fmt.Printf("Error: %s\n", err.Error())
 		os.Exit(1)
 	}
 
-	defer os.Remove(conf.GetSocketPath())
-
 	// Setup app
 	teaboxlib.NewUiConfig().Setup(conf)
 
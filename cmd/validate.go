@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"gitlab.com/isbm/teabox/teaboxlib"
+)
+
+// validate the whole module tree without starting the UI.
+// Returns an exit code, which is non-zero if at least one problem was found.
+func validate(appname string) int {
+	conf, err := teaboxlib.NewTeaConf(appname)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return 1
+	}
+
+	// Modules are loaded the same way, as for the UI, so every problem is reported by the parsers
+	if err := conf.InitConfig(); err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return 1
+	}
+
+	problems := conf.GetErrors()
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].GetFile() != problems[j].GetFile() {
+			return problems[i].GetFile() < problems[j].GetFile()
+		}
+		return problems[i].GetKey() < problems[j].GetKey()
+	})
+
+	for _, p := range problems {
+		fmt.Println(p.Error())
+	}
+
+	if len(problems) > 0 {
+		fmt.Printf("\n%d problem(s) found in %s\n", len(problems), conf.GetContentPath())
+		return 1
+	}
+
+	fmt.Printf("No problems found in %s\n", conf.GetContentPath())
+	return 0
+}
@@ -50,13 +50,15 @@
 
 # Standard Socket Location
 # ------------------------
+# Teabox tells where its socket is, as more than one module can run at a time.
 # You can surely redefine it here for everything or overwrite it in your module.
-SOCK="/tmp/teabox.sock"
+SOCK="$TEABOX_SOCKET"
 
 
 # Call socket with an API call
 # ----------------------------
-# This requires OpenBSD's netcat installed and availble as "nc".
+# The call is sent by Teabox itself ("teabox call"), if TEABOX_BIN tells where it is.
+# Otherwise this requires OpenBSD's netcat installed and availble as "nc".
 # It takes one required parameter and two optional:
 #
 #     api <API> [VALUE] [TYPE]
@@ -66,13 +68,28 @@ SOCK="/tmp/teabox.sock"
 #     api logger-status "Hello world"
 #     api init-alloc-progress 3 int
 #     api field-set-by-ord "{3}false" bool
+#     NAME=$(api session.get "{name}")
 #
+# The result of the call, if any, is printed, and an error is printed to STDERR.
+# The token of the run is sent with each call, if Teabox has passed it.
 # For more info about API, refer to the documentation.
 #
 function api() {
     cls=$1
     msg=$2
     typ=$3
+    if [[ -n "$TEABOX_BIN" ]]; then
+        "$TEABOX_BIN" call -s "$SOCK" "$cls:$typ:$msg"
+        ret=$?
+        if [[ "$ret" == "2" ]]; then
+            exit 1
+        fi
+        return $ret
+    fi
+
+    if [[ -n "$TEABOX_TOKEN" ]]; then
+        cls="$cls@$TEABOX_TOKEN"
+    fi
     $(echo "$cls:$typ:$msg" | nc -w0 -U $SOCK)
     if [[ "$?" == "1" ]]; then
         exit 1
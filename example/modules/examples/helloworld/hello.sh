@@ -34,6 +34,10 @@ else
 		setup
 		break
 		;;
+	    --name)
+		# No name of the world is not an error, but there is nothing to do
+		exit 3
+		;;
 	    --name=*)
 		run "${1#*=}"
 		break
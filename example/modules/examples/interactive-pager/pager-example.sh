@@ -0,0 +1,14 @@
+#!/usr/bin/bash
+
+for arg in "$@"; do
+    case "$arg" in
+	--file=*) FILE=${arg#--file=} ;;
+    esac
+done
+
+if [ ! -r "$FILE" ]; then
+    echo "Cannot read \"$FILE\""
+    exit 1
+fi
+
+${PAGER:-less} "$FILE"
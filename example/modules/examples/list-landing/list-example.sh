@@ -0,0 +1,38 @@
+#!/usr/bin/bash
+
+SELF_PATH="$( cd -- "$(dirname "$0")" >/dev/null 2>&1 ; pwd -P )"
+source $SELF_PATH/../../../lib/shell/bash-common.sh
+
+function run() {
+    BASKET=$1
+
+    api list.title "Contents of the $BASKET basket"
+    api list.header '["Fruit", "Colour", "Price"]' json
+
+    # Take only the name of the fruit as the selected value
+    api list.value 1 int
+
+    # Each line is a row, columns are separated with a TAB
+    for fruit in "Apple	green	1.20" "Banana	yellow	0.80" "Cherry	red	4.50"
+    do
+	echo "$fruit"
+	sleep 0.1
+    done
+
+    # Rows can be also added with the API call
+    api list.add '[["Plum", "blue", "2.10"]]' json
+    api list.status "Pick your fruit"
+}
+
+while [[ $# -gt 0 ]]; do
+    case $1 in
+	--basket=*)
+	    run "${1#*=}"
+	    break
+	    ;;
+	*)
+	    run "Empty"
+	    break
+	    ;;
+    esac
+done
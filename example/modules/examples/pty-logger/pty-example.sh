@@ -0,0 +1,20 @@
+#!/usr/bin/bash
+
+if [ -t 1 ]; then
+    echo -e "Running in a terminal of \e[1m$(tput cols 2>/dev/null)x$(tput lines 2>/dev/null)\e[0m"
+else
+    echo "Not in a terminal, output is plain"
+fi
+echo
+
+for pkg in kernel glibc bash coreutils; do
+    for pct in 0 20 40 60 80 100; do
+	bar=$(printf "%$((pct / 5))s" | tr " " "#")
+	printf "\r\e[KGetting \e[36m%-10s\e[0m [%-20s] %3d%%" "$pkg" "$bar" "$pct"
+	sleep 0.1
+    done
+    printf "\r\e[KGetting \e[36m%-10s\e[0m \e[32mdone\e[0m\n" "$pkg"
+done
+
+echo
+echo -e "\e[1;32mAll things are downloaded\e[0m"
@@ -0,0 +1,24 @@
+#!/usr/bin/bash
+
+SELF_PATH="$( cd -- "$(dirname "$0")" >/dev/null 2>&1 ; pwd -P )"
+source $SELF_PATH/../../../lib/shell/bash-common.sh
+
+api terminal.title "Questions to answer"
+api terminal.status "Type the answers, Ctrl+C to abort"
+
+echo -e "Welcome to the \e[1;33mInteractive Terminal\e[0m example"
+echo
+
+read -p "What is your name? " name
+read -p "What is your favourite colour? " colour
+echo
+
+read -n 1 -p "Shall I say hello, $name? [y/n] " yes
+echo
+if [ "$yes" != "y" ]; then
+    echo "Fine, not saying anything"
+    exit 0
+fi
+
+echo -e "Hello, \e[1m$name\e[0m! \e[32m$colour\e[0m is a nice colour."
+sleep 1
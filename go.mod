@@ -9,6 +9,7 @@ require (
 	github.com/isbm/crtview v1.6.3-0.20251110170322-8b85223f17b2
 	github.com/isbm/go-nanoconf v0.0.0-20210917204429-663038ee6e05
 	github.com/karrick/godirwalk v1.17.0
+	golang.org/x/sys v0.8.0
 )
 
 require github.com/stretchr/testify v1.8.1
@@ -26,7 +27,6 @@ require (
 	github.com/rivo/uniseg v0.4.4 // indirect
 	github.com/sirupsen/logrus v1.9.0 // indirect
 	gitlab.com/tslocum/cbind v0.1.4 // indirect
-	golang.org/x/sys v0.8.0 // indirect
 	golang.org/x/term v0.8.0 // indirect
 	golang.org/x/text v0.9.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
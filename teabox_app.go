@@ -21,6 +21,7 @@ type TeaboxApplication struct {
 	callback *teaboxlib.TeaboxSocketServer
 	config   *teaboxlib.TeaConf
 	session  *teaboxlib.TeaboxRuntimeSession
+	history  *teaboxlib.TeaboxJobHistory
 
 	*crtview.Application
 }
@@ -41,6 +42,14 @@ func (ta *TeaboxApplication) GetSession() *teaboxlib.TeaboxRuntimeSession {
 	return ta.session
 }
 
+// GetHistory of the jobs. It is loaded on the first access, once the UI configuration is set.
+func (ta *TeaboxApplication) GetHistory() *teaboxlib.TeaboxJobHistory {
+	if ta.history == nil {
+		ta.history = teaboxlib.NewTeaboxJobHistory(teaboxlib.HISTORY_FILENAME, teaboxlib.HISTORY_SIZE)
+	}
+	return ta.history
+}
+
 // GetGlobalConfig of the application
 func (ta *TeaboxApplication) GetGlobalConfig() *teaboxlib.TeaConf {
 	return ta.config
@@ -49,6 +58,9 @@ func (ta *TeaboxApplication) GetGlobalConfig() *teaboxlib.TeaConf {
 // Stop application
 func (ta *TeaboxApplication) Stop(message string) {
 	__MSG_REF = message
+	if err := teaboxlib.CleanupRuntimeSockets(); err != nil {
+		AddToFile(teaboxlib.LOG_FILENAME, fmt.Sprintf("Error: unable to remove the sockets of the runs: %s", err.Error()))
+	}
 	ta.Application.Stop()
 }
 
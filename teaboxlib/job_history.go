@@ -0,0 +1,425 @@
+package teaboxlib
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// States of a command in a job
+const (
+	JOB_STATE_DONE      = "done"
+	JOB_STATE_FAILED    = "failed"
+	JOB_STATE_CANCELLED = "cancelled"
+	JOB_STATE_SKIPPED   = "skipped"
+	JOB_STATE_NOT_RUN   = "not run"
+)
+
+// JOB_SECRET_MASK replaces the values of the secret arguments, such as passwords, in the history
+const JOB_SECRET_MASK = "********"
+
+// TeaboxJobCommand is one command of a job, as it was called
+type TeaboxJobCommand struct {
+	title    string
+	path     string
+	args     []string
+	masked   bool
+	state    string
+	exitCode int
+}
+
+// GetTitle of the command
+func (jc *TeaboxJobCommand) GetTitle() string {
+	return jc.title
+}
+
+// GetCommandPath returns the path of the executable
+func (jc *TeaboxJobCommand) GetCommandPath() string {
+	return jc.path
+}
+
+// GetArguments of the command. The secret values are replaced with JOB_SECRET_MASK.
+func (jc *TeaboxJobCommand) GetArguments() []string {
+	return jc.args
+}
+
+// IsMasked returns true, if some values of the arguments were secret and are not stored
+func (jc *TeaboxJobCommand) IsMasked() bool {
+	return jc.masked
+}
+
+// GetState of the command, one of JOB_STATE_*
+func (jc *TeaboxJobCommand) GetState() string {
+	return jc.state
+}
+
+// GetExitCode of the command. It is -1, if the command did not quit on its own.
+func (jc *TeaboxJobCommand) GetExitCode() int {
+	return jc.exitCode
+}
+
+// SetResult of the command, once it is finished
+func (jc *TeaboxJobCommand) SetResult(state string, exitCode int) *TeaboxJobCommand {
+	jc.state, jc.exitCode = state, exitCode
+	return jc
+}
+
+// TeaboxJobRecord is a run of a module, with all its commands
+type TeaboxJobRecord struct {
+	id         string
+	module     string
+	commands   []*TeaboxJobCommand
+	started    time.Time
+	finished   time.Time
+	exitCode   int
+	transcript string
+}
+
+// NewTeaboxJobRecord constructor. The job is started now.
+func NewTeaboxJobRecord(module string) *TeaboxJobRecord {
+	now := time.Now()
+	return &TeaboxJobRecord{
+		id:       fmt.Sprintf("%d", now.UnixNano()),
+		module:   module,
+		commands: []*TeaboxJobCommand{},
+		started:  now,
+		exitCode: -1,
+	}
+}
+
+// GetId of the job, unique within the history
+func (jr *TeaboxJobRecord) GetId() string {
+	return jr.id
+}
+
+// GetModuleTitle returns the title of the module, which was run
+func (jr *TeaboxJobRecord) GetModuleTitle() string {
+	return jr.module
+}
+
+// GetCommands of the job, in the order of the module commands
+func (jr *TeaboxJobRecord) GetCommands() []*TeaboxJobCommand {
+	return jr.commands
+}
+
+// GetStarted returns the time when the job was started
+func (jr *TeaboxJobRecord) GetStarted() time.Time {
+	return jr.started
+}
+
+// GetFinished returns the time when the job was finished
+func (jr *TeaboxJobRecord) GetFinished() time.Time {
+	return jr.finished
+}
+
+// GetExitCode of the job, which is the one of its last called command
+func (jr *TeaboxJobRecord) GetExitCode() int {
+	return jr.exitCode
+}
+
+// GetTranscript returns the output of the job, if it was shown by a logger
+func (jr *TeaboxJobRecord) GetTranscript() string {
+	return jr.transcript
+}
+
+// GetState of the job: cancelled or failed, if any of its commands was, done otherwise
+func (jr *TeaboxJobRecord) GetState() string {
+	for _, c := range jr.commands {
+		if c.state == JOB_STATE_CANCELLED || c.state == JOB_STATE_FAILED {
+			return c.state
+		}
+	}
+	return JOB_STATE_DONE
+}
+
+// IsMasked returns true, if the secret values of some arguments are not stored,
+// so the job cannot be repeated as is.
+func (jr *TeaboxJobRecord) IsMasked() bool {
+	for _, c := range jr.commands {
+		if c.masked {
+			return true
+		}
+	}
+	return false
+}
+
+// AddCommand to the job. The arguments are already masked, if there are secrets among them.
+// The command is "not run", until its result is set.
+func (jr *TeaboxJobRecord) AddCommand(title, path string, args []string, masked bool) *TeaboxJobCommand {
+	c := &TeaboxJobCommand{title: title, path: path, args: args, masked: masked, state: JOB_STATE_NOT_RUN, exitCode: -1}
+	jr.commands = append(jr.commands, c)
+
+	return c
+}
+
+// Finish the job now
+func (jr *TeaboxJobRecord) Finish(exitCode int, transcript string) *TeaboxJobRecord {
+	jr.finished = time.Now()
+	jr.exitCode = exitCode
+	jr.transcript = transcript
+
+	return jr
+}
+
+// Stored form of a job
+type jobRecordData struct {
+	Id         string
+	Module     string
+	Commands   []jobCommandData
+	Started    time.Time
+	Finished   time.Time
+	ExitCode   int
+	Transcript string
+}
+
+type jobCommandData struct {
+	Title    string
+	Path     string
+	Args     []string
+	Masked   bool
+	State    string
+	ExitCode int
+}
+
+// MarshalJSON of the job record
+func (jr *TeaboxJobRecord) MarshalJSON() ([]byte, error) {
+	data := jobRecordData{Id: jr.id, Module: jr.module, Commands: []jobCommandData{},
+		Started: jr.started, Finished: jr.finished, ExitCode: jr.exitCode, Transcript: jr.transcript}
+	for _, c := range jr.commands {
+		data.Commands = append(data.Commands, jobCommandData{Title: c.title, Path: c.path, Args: c.args,
+			Masked: c.masked, State: c.state, ExitCode: c.exitCode})
+	}
+
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON of the job record
+func (jr *TeaboxJobRecord) UnmarshalJSON(b []byte) error {
+	var data jobRecordData
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+
+	jr.id, jr.module, jr.started, jr.finished = data.Id, data.Module, data.Started, data.Finished
+	jr.exitCode, jr.transcript = data.ExitCode, data.Transcript
+	jr.commands = []*TeaboxJobCommand{}
+	for _, c := range data.Commands {
+		jr.commands = append(jr.commands, &TeaboxJobCommand{title: c.Title, path: c.Path, args: c.Args,
+			masked: c.Masked, state: c.State, exitCode: c.ExitCode})
+	}
+
+	return nil
+}
+
+/*
+TeaboxJobHistory keeps the records of the past jobs in a file, one JSON record per line.
+The new records are appended to the file, and once there are more than the limit of them,
+the file is replaced with the latest ones only.
+*/
+type TeaboxJobHistory struct {
+	path    string
+	size    int
+	records []*TeaboxJobRecord
+	mtx     sync.RWMutex
+}
+
+// Default file of the history is in the state directory of the user, as the XDG Base Directory
+// Specification tells, so it is writable without root. Relative $XDG_STATE_HOME is ignored.
+func defaultHistoryFilename() string {
+	state := os.Getenv("XDG_STATE_HOME")
+	if !filepath.IsAbs(state) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "/var/lib/teabox/history"
+		}
+		state = filepath.Join(home, ".local", "state")
+	}
+
+	return filepath.Join(state, "teabox", "history")
+}
+
+// NewTeaboxJobHistory constructor. Records are loaded from the file, if it exists.
+// Size is the maximum of the records to keep.
+func NewTeaboxJobHistory(path string, size int) *TeaboxJobHistory {
+	jh := &TeaboxJobHistory{path: path, size: size, records: []*TeaboxJobRecord{}}
+	jh.load()
+
+	return jh
+}
+
+// Load the records from the file
+func (jh *TeaboxJobHistory) load() {
+	jh.records = jh.trim(jh.read())
+}
+
+// Read all the records from the file. Broken ones are skipped.
+func (jh *TeaboxJobHistory) read() []*TeaboxJobRecord {
+	records := []*TeaboxJobRecord{}
+	f, err := os.Open(jh.path)
+	if err != nil {
+		return records
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 256*1024*1024) // Transcripts are long lines
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		rec := new(TeaboxJobRecord)
+		if json.Unmarshal(scanner.Bytes(), rec) == nil {
+			records = append(records, rec)
+		}
+	}
+
+	return records
+}
+
+// Keep only the latest records within the size
+func (jh *TeaboxJobHistory) trim(records []*TeaboxJobRecord) []*TeaboxJobRecord {
+	if len(records) > jh.size {
+		return records[len(records)-jh.size:]
+	}
+
+	return records
+}
+
+// IsEnabled returns true, if there are records kept at all
+func (jh *TeaboxJobHistory) IsEnabled() bool {
+	return jh.size > 0
+}
+
+// GetRecords of the history, the latest first
+func (jh *TeaboxJobHistory) GetRecords() []*TeaboxJobRecord {
+	jh.mtx.RLock()
+	defer jh.mtx.RUnlock()
+
+	records := []*TeaboxJobRecord{}
+	for idx := len(jh.records) - 1; idx >= 0; idx-- {
+		records = append(records, jh.records[idx])
+	}
+
+	return records
+}
+
+// Get a record by its ID. Returns nil, if there is no such record.
+func (jh *TeaboxJobHistory) Get(id string) *TeaboxJobRecord {
+	jh.mtx.RLock()
+	defer jh.mtx.RUnlock()
+
+	for _, rec := range jh.records {
+		if rec.id == id {
+			return rec
+		}
+	}
+
+	return nil
+}
+
+// Add a finished record to the history and save it. More instances of Teabox can share the same file,
+// so it is locked and read again, keeping the records of the others.
+func (jh *TeaboxJobHistory) Add(rec *TeaboxJobRecord) error {
+	if !jh.IsEnabled() {
+		return nil
+	}
+
+	jh.mtx.Lock()
+	defer jh.mtx.Unlock()
+
+	jh.records = jh.trim(append(jh.records, rec)) // Still seen in this instance, if it cannot be saved
+
+	unlock, err := jh.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	records := append(jh.read(), rec)
+	jh.records = jh.trim(records)
+	if len(records) > jh.size {
+		return jh.rewrite(jh.records)
+	}
+
+	return jh.append(rec)
+}
+
+// Lock the history against the other instances of Teabox. The lock is a separate file, as the history
+// itself is replaced on rewrite. Returns the function, which releases the lock.
+// History is private to the user, as the output may contain sensitive data.
+func (jh *TeaboxJobHistory) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(jh.path), 0o700); err != nil {
+		return nil, fmt.Errorf("unable to create history directory: %s", err.Error())
+	}
+
+	f, err := os.OpenFile(jh.path+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to lock history: %s", err.Error())
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("unable to lock history: %s", err.Error())
+	}
+
+	return func() { f.Close() }, nil // Closing releases the lock
+}
+
+// Append a record to the file
+func (jh *TeaboxJobHistory) append(rec *TeaboxJobRecord) error {
+	f, err := os.OpenFile(jh.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("unable to open history: %s", err.Error())
+	}
+	defer f.Close()
+
+	return jh.write(f, []*TeaboxJobRecord{rec})
+}
+
+// Replace the whole content of the file with the records. They are written to a temporary file first,
+// which is then renamed over the history, so it is never left half written.
+func (jh *TeaboxJobHistory) rewrite(records []*TeaboxJobRecord) error {
+	f, err := os.CreateTemp(filepath.Dir(jh.path), filepath.Base(jh.path)+".*") // Created as 0600
+	if err != nil {
+		return fmt.Errorf("unable to open history: %s", err.Error())
+	}
+	defer os.Remove(f.Name()) // Nothing to remove after the rename
+
+	if err := jh.write(f, records); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("unable to write history: %s", err.Error())
+	}
+	if err := os.Rename(f.Name(), jh.path); err != nil {
+		return fmt.Errorf("unable to write history: %s", err.Error())
+	}
+
+	return nil
+}
+
+// Write the records to the file, one per line
+func (jh *TeaboxJobHistory) write(f *os.File, records []*TeaboxJobRecord) error {
+	w := bufio.NewWriter(f)
+	for _, rec := range records {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("unable to write history: %s", err.Error())
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("unable to write history: %s", err.Error())
+	}
+
+	return nil
+}
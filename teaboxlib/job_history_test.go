@@ -0,0 +1,153 @@
+package teaboxlib
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TeaboxJobHistoryTestSuite struct {
+	suite.Suite
+	path string
+}
+
+func (s *TeaboxJobHistoryTestSuite) SetupTest() {
+	s.path = filepath.Join(s.T().TempDir(), "history", "jobs")
+}
+
+func (s *TeaboxJobHistoryTestSuite) record(module string, exitCode int) *TeaboxJobRecord {
+	rec := NewTeaboxJobRecord(module)
+	rec.AddCommand("Install", "/opt/mod/install", []string{"-v", "--name=foo", "--password=" + JOB_SECRET_MASK}, true).
+		SetResult(JOB_STATE_DONE, 0)
+	rec.AddCommand("Cleanup", "/opt/mod/cleanup", []string{}, false)
+
+	return rec.Finish(exitCode, "Hello\n[red]World[-]\n")
+}
+
+// Records survive a restart with all their data
+func (s *TeaboxJobHistoryTestSuite) TestPersistence() {
+	jh := NewTeaboxJobHistory(s.path, 10)
+	s.NoError(jh.Add(s.record("First", 0)))
+	s.NoError(jh.Add(s.record("Second", 2)))
+
+	info, err := os.Stat(s.path)
+	s.NoError(err)
+	s.Equal(os.FileMode(0o600), info.Mode().Perm())
+
+	records := NewTeaboxJobHistory(s.path, 10).GetRecords()
+	s.Len(records, 2)
+	s.Equal("Second", records[0].GetModuleTitle())
+	s.Equal(2, records[0].GetExitCode())
+	s.Equal("Hello\n[red]World[-]\n", records[0].GetTranscript())
+	s.True(records[0].IsMasked())
+	s.Equal(JOB_STATE_DONE, records[0].GetState())
+	s.False(records[0].GetFinished().Before(records[0].GetStarted()))
+
+	cmds := records[1].GetCommands()
+	s.Len(cmds, 2)
+	s.Equal("/opt/mod/install", cmds[0].GetCommandPath())
+	s.Equal([]string{"-v", "--name=foo", "--password=" + JOB_SECRET_MASK}, cmds[0].GetArguments())
+	s.Equal(JOB_STATE_DONE, cmds[0].GetState())
+	s.Equal(JOB_STATE_NOT_RUN, cmds[1].GetState())
+	s.Equal(-1, cmds[1].GetExitCode())
+
+	cmds[1].SetResult(JOB_STATE_FAILED, 1)
+	s.Equal(JOB_STATE_FAILED, records[1].GetState())
+
+	s.Equal(records[0], NewTeaboxJobHistory(s.path, 10).Get(records[0].GetId()))
+	s.Nil(jh.Get("nonexistent"))
+	s.Equal(records[1].GetModuleTitle(), NewTeaboxJobHistory(s.path, 10).Get(records[1].GetId()).GetModuleTitle())
+}
+
+// Only the latest records are kept
+func (s *TeaboxJobHistoryTestSuite) TestSize() {
+	jh := NewTeaboxJobHistory(s.path, 2)
+	for _, m := range []string{"One", "Two", "Three"} {
+		s.NoError(jh.Add(s.record(m, 0)))
+	}
+
+	records := NewTeaboxJobHistory(s.path, 2).GetRecords()
+	s.Len(records, 2)
+	s.Equal("Three", records[0].GetModuleTitle())
+	s.Equal("Two", records[1].GetModuleTitle())
+
+	data, err := os.ReadFile(s.path)
+	s.NoError(err)
+	s.Equal(2, strings.Count(string(data), "\n"))
+}
+
+// Instances of Teabox, sharing the file, keep the records of each other
+func (s *TeaboxJobHistoryTestSuite) TestSharedFile() {
+	first := NewTeaboxJobHistory(s.path, 3)
+	second := NewTeaboxJobHistory(s.path, 3)
+	s.NoError(first.Add(s.record("One", 0)))
+	s.NoError(second.Add(s.record("Two", 0)))
+	s.NoError(first.Add(s.record("Three", 0)))
+	s.NoError(second.Add(s.record("Four", 0)))
+
+	for _, jh := range []*TeaboxJobHistory{second, NewTeaboxJobHistory(s.path, 3)} {
+		titles := []string{}
+		for _, rec := range jh.GetRecords() {
+			titles = append(titles, rec.GetModuleTitle())
+		}
+		s.Equal([]string{"Four", "Three", "Two"}, titles)
+	}
+
+	// Rewritten file replaces the old one, no temporary files are left behind
+	info, err := os.Stat(s.path)
+	s.NoError(err)
+	s.Equal(os.FileMode(0o600), info.Mode().Perm())
+
+	files, err := filepath.Glob(filepath.Join(filepath.Dir(s.path), "*"))
+	s.NoError(err)
+	s.ElementsMatch([]string{s.path, s.path + ".lock"}, files)
+}
+
+// Broken lines do not prevent loading the rest
+func (s *TeaboxJobHistoryTestSuite) TestBrokenRecord() {
+	jh := NewTeaboxJobHistory(s.path, 10)
+	s.NoError(jh.Add(s.record("One", 0)))
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	s.NoError(err)
+	_, err = f.WriteString("{broken\n")
+	s.NoError(err)
+	s.NoError(f.Close())
+
+	s.Len(NewTeaboxJobHistory(s.path, 10).GetRecords(), 1)
+}
+
+// Zero size disables the history
+func (s *TeaboxJobHistoryTestSuite) TestDisabled() {
+	jh := NewTeaboxJobHistory(s.path, 0)
+	s.False(jh.IsEnabled())
+	s.NoError(jh.Add(s.record("One", 0)))
+	s.Empty(jh.GetRecords())
+
+	_, err := os.Stat(s.path)
+	s.True(os.IsNotExist(err))
+}
+
+// History is in the state directory of the user by default
+func (s *TeaboxJobHistoryTestSuite) TestDefaultFilename() {
+	s.T().Setenv("XDG_STATE_HOME", "/tmp/state")
+	s.Equal("/tmp/state/teabox/history", defaultHistoryFilename())
+
+	s.T().Setenv("XDG_STATE_HOME", "state")
+	s.T().Setenv("HOME", "/home/frodo")
+	s.Equal("/home/frodo/.local/state/teabox/history", defaultHistoryFilename())
+}
+
+// History, which cannot be written, tells why
+func (s *TeaboxJobHistoryTestSuite) TestNotWritable() {
+	s.NoError(os.WriteFile(filepath.Dir(s.path), []byte{}, 0o600)) // File instead of the directory
+	jh := NewTeaboxJobHistory(s.path, 10)
+	s.Error(jh.Add(s.record("One", 0)))
+}
+
+func TestTeaboxJobHistoryTestSuite(t *testing.T) {
+	suite.Run(t, new(TeaboxJobHistoryTestSuite))
+}
@@ -21,12 +21,20 @@ func NewTeaboxRuntimeSession() *TeaboxRuntimeSession {
 	return rts
 }
 
+// Get a storage key. Public keys, prefixed with ":" colon, are not prefixed with the module name.
+func (rts *TeaboxRuntimeSession) key(modname, k string) string {
+	if strings.HasPrefix(k, ":") {
+		return k
+	}
+	return fmt.Sprintf("%s-%s", modname, k)
+}
+
 // Set value to the session storage
 func (rts *TeaboxRuntimeSession) Set(modname, k string, v interface{}) {
 	rts.mtx.Lock()
 	defer rts.mtx.Unlock()
 
-	rts.kws[fmt.Sprintf("%s-%s", modname, k)] = v
+	rts.kws[rts.key(modname, k)] = v
 }
 
 // Get value from the session storage
@@ -34,7 +42,7 @@ func (rts *TeaboxRuntimeSession) Get(modname, k string) interface{} {
 	rts.mtx.RLock()
 	defer rts.mtx.RUnlock()
 
-	v, ok := rts.kws[fmt.Sprintf("%s-%s", modname, k)]
+	v, ok := rts.kws[rts.key(modname, k)]
 	if !ok {
 		return nil
 	}
@@ -50,7 +58,9 @@ func (rts *TeaboxRuntimeSession) Keys(modname string) []string {
 
 	keys := []string{}
 	for k := range rts.kws {
-		if strings.HasPrefix(k, modname+"-") || strings.HasPrefix(k, ":") {
+		if strings.HasPrefix(k, ":") {
+			keys = append(keys, k)
+		} else if strings.HasPrefix(k, modname+"-") {
 			k = k[len(modname)+1:]
 			if k != "" {
 				keys = append(keys, k)
@@ -60,11 +70,13 @@ func (rts *TeaboxRuntimeSession) Keys(modname string) []string {
 
 	return keys
 }
+
+// Delete a value from the session storage
 func (rts *TeaboxRuntimeSession) Delete(modname, k string) {
 	rts.mtx.Lock()
 	defer rts.mtx.Unlock()
 
-	delete(rts.kws, fmt.Sprintf("%s-%s", modname, k))
+	delete(rts.kws, rts.key(modname, k))
 }
 
 // Delete the entire session for the module. This does not affect
@@ -79,6 +91,6 @@ func (rts *TeaboxRuntimeSession) Flush(modname string) {
 		if strings.HasPrefix(k, ":") {
 			continue
 		}
-		delete(rts.kws, k)
+		delete(rts.kws, rts.key(modname, k))
 	}
 }
@@ -0,0 +1,46 @@
+package teaboxlib
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TeaboxRuntimeSessionTestSuite struct {
+	suite.Suite
+	session *TeaboxRuntimeSession
+}
+
+func (s *TeaboxRuntimeSessionTestSuite) SetupTest() {
+	s.session = NewTeaboxRuntimeSession()
+}
+
+// Private keys are not visible to the other modules
+func (s *TeaboxRuntimeSessionTestSuite) TestPrivateKeys() {
+	s.session.Set("foo", "name", "John Smith")
+	s.Equal("John Smith", s.session.Get("foo", "name"))
+	s.Nil(s.session.Get("bar", "name"))
+}
+
+// Public keys are shared across the modules, and survive a flush
+func (s *TeaboxRuntimeSessionTestSuite) TestPublicKeys() {
+	s.session.Set("foo", ":name", "John Smith")
+	s.session.Set("foo", "age", 42)
+	s.Equal("John Smith", s.session.Get("bar", ":name"))
+
+	keys := s.session.Keys("foo")
+	sort.Strings(keys)
+	s.Equal([]string{":name", "age"}, keys)
+
+	s.session.Flush("foo")
+	s.Nil(s.session.Get("foo", "age"))
+	s.Equal("John Smith", s.session.Get("foo", ":name"))
+
+	s.session.Delete("bar", ":name")
+	s.Nil(s.session.Get("foo", ":name"))
+}
+
+func TestTeaboxRuntimeSessionTestSuite(t *testing.T) {
+	suite.Run(t, new(TeaboxRuntimeSessionTestSuite))
+}
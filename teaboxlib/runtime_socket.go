@@ -0,0 +1,110 @@
+package teaboxlib
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Environment, which is passed to the commands of a run, so they know where to call back
+const (
+	ENV_SOCKET = "TEABOX_SOCKET"
+	ENV_MODULE = "TEABOX_MODULE"
+	ENV_RUN_ID = "TEABOX_RUN_ID"
+	ENV_TOKEN  = "TEABOX_TOKEN"
+	ENV_BIN    = "TEABOX_BIN"
+)
+
+// GetRuntimeDir returns a private directory for the Unix sockets of the runs. It is "teabox" in
+// $XDG_RUNTIME_DIR, or "teabox-<uid>" in the temporary directory, if there is no runtime directory.
+// The directory is created, if needed, and it must be accessible only by its owner.
+func GetRuntimeDir() (string, error) {
+	dir := filepath.Join(os.TempDir(), fmt.Sprintf("teabox-%d", os.Getuid()))
+	if xdg := os.Getenv("XDG_RUNTIME_DIR"); xdg != "" {
+		dir = filepath.Join(xdg, "teabox")
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+
+	// Directory in a shared location could be made by someone else beforehand
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("runtime directory %s is not a directory", dir)
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		return "", fmt.Errorf("runtime directory %s is owned by another user", dir)
+	}
+	if info.Mode().Perm() != 0o700 {
+		if err := os.Chmod(dir, 0o700); err != nil {
+			return "", err
+		}
+	}
+
+	return dir, nil
+}
+
+// NewRuntimeSocketPath returns a path of the Unix socket for a run, which is unique per instance
+// of Teabox and its user.
+func NewRuntimeSocketPath(runId string) (string, error) {
+	dir, err := GetRuntimeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, fmt.Sprintf("%d-%s.sock", os.Getpid(), runId)), nil
+}
+
+// NewRunToken returns a random token for a run, which its commands send with the calls
+func NewRunToken() (string, error) {
+	data := make([]byte, 16)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(data), nil
+}
+
+// GetRunEnv returns the environment for the commands of a run of a module. It also tells
+// where Teabox itself is, so the commands can send the API calls with its "call" command.
+func GetRunEnv(socket, module, runId, token string) []string {
+	bin, err := os.Executable()
+	if err != nil {
+		bin = os.Args[0]
+	}
+
+	return []string{
+		fmt.Sprintf("%s=%s", ENV_SOCKET, socket),
+		fmt.Sprintf("%s=%s", ENV_MODULE, module),
+		fmt.Sprintf("%s=%s", ENV_RUN_ID, runId),
+		fmt.Sprintf("%s=%s", ENV_TOKEN, token),
+		fmt.Sprintf("%s=%s", ENV_BIN, bin),
+	}
+}
+
+// CleanupRuntimeSockets removes the Unix sockets of all runs of this instance, once it quits
+func CleanupRuntimeSockets() error {
+	dir, err := GetRuntimeDir()
+	if err != nil {
+		return err
+	}
+
+	sockets, err := filepath.Glob(filepath.Join(dir, fmt.Sprintf("%d-*.sock", os.Getpid())))
+	if err != nil {
+		return err
+	}
+	for _, socket := range sockets {
+		if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package teaboxlib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TeaboxRuntimeSocketTestSuite struct {
+	suite.Suite
+	xdg string
+}
+
+func (s *TeaboxRuntimeSocketTestSuite) SetupTest() {
+	s.xdg = s.T().TempDir()
+	s.T().Setenv("XDG_RUNTIME_DIR", s.xdg)
+}
+
+// Sockets are in a private directory and unique per instance and run
+func (s *TeaboxRuntimeSocketTestSuite) TestSocketPath() {
+	first, err := NewRuntimeSocketPath("1")
+	s.NoError(err)
+	second, err := NewRuntimeSocketPath("2")
+	s.NoError(err)
+
+	s.NotEqual(first, second)
+	s.Equal(filepath.Join(s.xdg, "teabox"), filepath.Dir(first))
+	s.Equal(fmt.Sprintf("%d-1.sock", os.Getpid()), filepath.Base(first))
+
+	info, err := os.Stat(filepath.Dir(first))
+	s.NoError(err)
+	s.Equal(os.FileMode(0o700), info.Mode().Perm())
+}
+
+// Permissions of an existing directory are fixed, but not a file in its place
+func (s *TeaboxRuntimeSocketTestSuite) TestExistingDir() {
+	dir := filepath.Join(s.xdg, "teabox")
+	s.NoError(os.Mkdir(dir, 0o755))
+
+	_, err := GetRuntimeDir()
+	s.NoError(err)
+	info, err := os.Stat(dir)
+	s.NoError(err)
+	s.Equal(os.FileMode(0o700), info.Mode().Perm())
+
+	s.NoError(os.Remove(dir))
+	s.NoError(os.WriteFile(dir, []byte{}, 0o600))
+	_, err = GetRuntimeDir()
+	s.Error(err)
+}
+
+// Without the runtime directory, the sockets are in the temporary directory, per user
+func (s *TeaboxRuntimeSocketTestSuite) TestNoRuntimeDir() {
+	s.T().Setenv("XDG_RUNTIME_DIR", "")
+	s.T().Setenv("TMPDIR", s.xdg)
+
+	dir, err := GetRuntimeDir()
+	s.NoError(err)
+	s.Equal(filepath.Join(s.xdg, fmt.Sprintf("teabox-%d", os.Getuid())), dir)
+}
+
+// Socket of the server is accessible only by its owner
+func (s *TeaboxRuntimeSocketTestSuite) TestSocketMode() {
+	pth, err := NewRuntimeSocketPath("mode")
+	s.NoError(err)
+
+	server := NewTeaboxSocketServer().AddGlobalAction(func(*TeaboxAPICall) string { return "" })
+	s.NoError(server.Start(pth))
+	defer server.Stop()
+
+	info, err := os.Stat(pth)
+	s.NoError(err)
+	s.Equal(os.FileMode(0o600), info.Mode().Perm())
+	s.Equal(pth, server.GetSocketPath())
+}
+
+// Only the sockets of this instance are removed on quit
+func (s *TeaboxRuntimeSocketTestSuite) TestCleanup() {
+	own, err := NewRuntimeSocketPath("1")
+	s.NoError(err)
+	other := filepath.Join(filepath.Dir(own), "1-1.sock")
+	for _, pth := range []string{own, other} {
+		s.NoError(os.WriteFile(pth, []byte{}, 0o600))
+	}
+
+	s.NoError(CleanupRuntimeSockets())
+	_, err = os.Stat(own)
+	s.True(os.IsNotExist(err))
+	_, err = os.Stat(other)
+	s.NoError(err)
+}
+
+func (s *TeaboxRuntimeSocketTestSuite) TestRunEnv() {
+	bin, err := os.Executable()
+	s.NoError(err)
+	s.Equal([]string{"TEABOX_SOCKET=/run/user/1000/teabox/1-2.sock", "TEABOX_MODULE=disk", "TEABOX_RUN_ID=2", "TEABOX_TOKEN=abc",
+		"TEABOX_BIN=" + bin}, GetRunEnv("/run/user/1000/teabox/1-2.sock", "disk", "2", "abc"))
+
+	first, err := NewRunToken()
+	s.NoError(err)
+	second, err := NewRunToken()
+	s.NoError(err)
+	s.Len(first, 32)
+	s.NotEqual(first, second)
+}
+
+func TestTeaboxRuntimeSocketTestSuite(t *testing.T) {
+	suite.Run(t, new(TeaboxRuntimeSocketTestSuite))
+}
@@ -8,6 +8,7 @@ import (
 
 type SigCall struct {
 	modCmd *TeaConfModCommand
+	env    []string
 }
 
 // NewSigCall creates
@@ -17,6 +18,12 @@ func NewSigCall(mc *TeaConfModCommand) *SigCall {
 	return sc
 }
 
+// SetEnv adds the environment to the signal command, e.g. the socket of the run
+func (sc *SigCall) SetEnv(env []string) *SigCall {
+	sc.env = env
+	return sc
+}
+
 // CallSignal action of the widget (any)
 func (sc *SigCall) CallSignal(act *TeaConfArgSignalAction) {
 	if err := sc.call(act); err != nil {
@@ -34,7 +41,9 @@ func (sc *SigCall) call(act *TeaConfArgSignalAction) error {
 	}
 
 	pth := path.Dir(sc.modCmd.GetCommandPath())
-	out, err := exec.Command(path.Join(pth, act.GetName()), act.GetArguments()...).CombinedOutput()
+	cmd := exec.Command(path.Join(pth, act.GetName()), act.GetArguments()...)
+	cmd.Env = append(sc.modCmd.getGlobalEnviron(), sc.env...)
+	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("error: %v\n%v", err.Error(), out)
 	}
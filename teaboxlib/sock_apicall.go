@@ -54,12 +54,32 @@ string-encoded with proper escaping, e.g.:
 
 This data structure is supported for exceptional cases and should be used sparingly. Because there
 is no need to overuse JSON everywhere, since sometimes things needs to be just as simple as possible. :)
+
+Authentication:
+
+The class can be followed by the token of the run, which is passed to the module in TEABOX_TOKEN
+environment variable, separated by "@":
+
+	LOGGER-STATUS@2f6b1e9c0d7a4e53:string:Hello world!
+
+Errors:
+
+A call, which cannot be parsed or its payload is not of its type (e.g. "int:abc"), has an error
+and is not passed to the actions. An action marks the call as handled, once it did it, or sets
+an error, why it could not. The call, which no action handled, is replied with the error. An action,
+which has nothing the call is about (e.g. a form without such field), tells it with a "not found" error
+instead, which is only replied, if no other action handled the call or set an error.
 */
 type TeaboxAPICall struct {
 	class    string
+	token    string
 	datatype string
 	key      string
 	payload  interface{}
+	handled  bool
+	err      error
+	notFound error
+	closed   <-chan struct{}
 }
 
 func NewTeaboxAPICall(data []byte) *TeaboxAPICall {
@@ -71,11 +91,14 @@ func NewTeaboxAPICall(data []byte) *TeaboxAPICall {
 func (ac *TeaboxAPICall) parse(data []byte) {
 	tokens := strings.SplitN(strings.TrimSpace(string(data)), ":", 3)
 	if len(tokens) != 3 {
+		ac.err = fmt.Errorf("malformed call, expected <CLASS>:[TYPE]:[PAYLOAD]")
 		return
 	}
 
-	// Set API class
-	ac.class = strings.ToLower(tokens[0])
+	// Set API class and the token, if any
+	class, token, _ := strings.Cut(tokens[0], "@")
+	ac.class = strings.ToLower(class)
+	ac.token = token
 
 	// Set supported types
 	switch tokens[1] {
@@ -97,6 +120,31 @@ func (ac *TeaboxAPICall) parse(data []byte) {
 	} else {
 		ac.payload = tokens[2]
 	}
+
+	ac.err = ac.checkPayload()
+}
+
+// Check if the payload can be converted to the type of the call
+func (ac *TeaboxAPICall) checkPayload() error {
+	v := strings.TrimSpace(fmt.Sprintf("%v", ac.payload))
+	switch ac.datatype {
+	case "int":
+		if _, err := strconv.Atoi(v); err != nil {
+			return fmt.Errorf("cannot convert \"%s\" to int", v)
+		}
+	case "bool":
+		switch strings.ToLower(v) {
+		case "true", "false", "yes", "no":
+		default:
+			return fmt.Errorf("cannot convert \"%s\" to bool", v)
+		}
+	case "json":
+		if !json.Valid([]byte(v)) {
+			return fmt.Errorf("payload is not a valid JSON")
+		}
+	}
+
+	return nil
 }
 
 // GetClass of the API call (address to what section)
@@ -104,6 +152,57 @@ func (ac *TeaboxAPICall) GetClass() string {
 	return ac.class
 }
 
+// GetToken of the run, which the call was sent with. Empty, if there is none.
+func (ac *TeaboxAPICall) GetToken() string {
+	return ac.token
+}
+
+// SetHandled marks the call as done by an action
+func (ac *TeaboxAPICall) SetHandled() {
+	ac.handled = true
+}
+
+// IsHandled returns true, if an action did the call
+func (ac *TeaboxAPICall) IsHandled() bool {
+	return ac.handled
+}
+
+// SetError tells why the call could not be done by an action. The first error is kept.
+func (ac *TeaboxAPICall) SetError(err error) {
+	if ac.err == nil {
+		ac.err = err
+	}
+}
+
+// SetNotFound tells that an action has nothing, the call is about, e.g. no such field on its form.
+// The call goes to all the actions, and only one of them might have it, so this error is only kept,
+// if no action did the call and none of them set an error with SetError. The first one is kept.
+func (ac *TeaboxAPICall) SetNotFound(err error) {
+	if ac.notFound == nil {
+		ac.notFound = err
+	}
+}
+
+// GetError of the call, if it could not be parsed or done
+func (ac *TeaboxAPICall) GetError() error {
+	if ac.err == nil && !ac.handled {
+		return ac.notFound
+	}
+	return ac.err
+}
+
+// Set the channel, which is closed together with the connection of the call
+func (ac *TeaboxAPICall) setClosed(closed <-chan struct{}) *TeaboxAPICall {
+	ac.closed = closed
+	return ac
+}
+
+// GetClosed returns a channel, which is closed once the connection of the call is closed, so its
+// action, which waits for something, can give up. It is nil, if the connection is not watched.
+func (ac *TeaboxAPICall) GetClosed() <-chan struct{} {
+	return ac.closed
+}
+
 // GetType returns a type of the payload to cast to
 func (ac *TeaboxAPICall) GetType() string {
 	return ac.datatype
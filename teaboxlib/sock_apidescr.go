@@ -52,6 +52,61 @@ var LOGGER_STATUS string = "logger.status"
 
 var LOGGER_TITLE string = "logger.title"
 
+// # List (lander)
+// ---------------
+//
+// Set title of the list window. Example usage:
+//
+//	list.title::"Available Images"
+
+var LIST_TITLE string = "list.title"
+
+// Set status of the list window. Example usage:
+//
+//	list.status::"Found 42 images"
+
+var LIST_STATUS string = "list.status"
+
+// Set column labels of the list as JSON array of strings. Example usage:
+//
+//	list.header:json:'["Name", "Size"]'
+
+var LIST_HEADER string = "list.header"
+
+// Add rows to the bottom of the list, as JSON two-dimensional array. Example usage:
+//
+//	list.add:json:'[["debian", "1.2G"], ["alpine", "50M"]]'
+
+var LIST_ADD string = "list.add"
+
+// Remove all rows from the list. Example usage:
+//
+//	list.clear::
+
+var LIST_CLEAR string = "list.clear"
+
+// Set a column, which value is taken from the selected row, starting from 1.
+// By default, the whole row is taken, separated by a comma. Example usage:
+//
+//	list.value:int:1
+
+var LIST_VALUE string = "list.value"
+
+// # Terminal (lander)
+// -------------------
+//
+// Set title of the terminal window. Example usage:
+//
+//	terminal.title::"Partitioning Tool"
+
+var TERMINAL_TITLE string = "terminal.title"
+
+// Set status of the terminal window. Example usage:
+//
+//	terminal.status::"Ctrl+C to abort"
+
+var TERMINAL_STATUS string = "terminal.status"
+
 /*
 Common lander is used for a "common feedback" and has the following features:
 - A cheklist of things that are going to happen
@@ -231,3 +286,122 @@ var FORM_SET_TABLE_BY_ORD string = "field.table.set.by-ord"
 
 // Clear all data from a table view.
 var FORM_CLR_TABLE_BY_ORD string = "field.table.clear.by-ord"
+
+// Get the current value of a field by its label. This is the value, which the command
+// gets for the argument of the field, or "true"/"false" for a toggle. Password or masked
+// fields return only the mask, if they are set. Example:
+//
+//	field.get.by-label::{Shadow Location}
+var FORM_GET_BY_LABEL string = "field.get.by-label"
+
+// Get the current value of a field by its order, same as by its label. Example:
+//
+//	field.get.by-ord::{0}
+var FORM_GET_BY_ORD string = "field.get.by-ord"
+
+// Hide a field by its label. The form is laid out without it, and its argument is not passed
+// to the command. If the field has the focus, it moves to the next one. Example:
+//
+//	field.hide.by-label::{Shadow Location}
+var FORM_HIDE_BY_LABEL string = "field.hide.by-label"
+var FORM_HIDE_BY_ORD string = "field.hide.by-ord"
+
+// Show a hidden field again. Example:
+//
+//	field.show.by-ord::{0}
+var FORM_SHOW_BY_LABEL string = "field.show.by-label"
+var FORM_SHOW_BY_ORD string = "field.show.by-ord"
+
+// Disable a field. It stays on the form, dimmed, but its value can not be changed, and its
+// argument is not passed to the command. Example:
+//
+//	field.disable.by-label::{Shadow Location}
+var FORM_DISABLE_BY_LABEL string = "field.disable.by-label"
+var FORM_DISABLE_BY_ORD string = "field.disable.by-ord"
+
+// Enable a disabled field again. Example:
+//
+//	field.enable.by-ord::{0}
+var FORM_ENABLE_BY_LABEL string = "field.enable.by-label"
+var FORM_ENABLE_BY_ORD string = "field.enable.by-ord"
+
+// Get the current values of all fields of all forms of the module, as a JSON array
+// of the forms in the order of the commands, each with its values by the labels
+// of the fields. Password or masked fields are masked:
+//
+//	form.dump::
+//
+// Returns e.g.:
+//
+//	[{"title": "Shadow", "fields": {"Shadow Location": "/etc/shadow", "Verbose": true}}]
+var FORM_DUMP string = "form.dump"
+
+// # Session (any)
+// ---------------
+//
+// Set a value to the session of the module using a key. Example usage:
+//
+//	session.set::{name}John Smith
+
+var SESSION_SET string = "session.set"
+
+// Get a value from the session by its key. The value is replied. Example usage:
+//
+//	session.get::{name}
+
+var SESSION_GET string = "session.get"
+
+// Get the keys of the session, replied as a comma-separated list. Example usage:
+//
+//	session.keys::
+
+var SESSION_KEYS string = "session.keys"
+
+// Delete a value from the session by its key. Example usage:
+//
+//	session.delete::name
+
+var SESSION_DELETE string = "session.delete"
+
+// Flush the entire session of the module. Example usage:
+//
+//	session.flush::
+
+var SESSION_FLUSH string = "session.flush"
+
+// # Dialogs (any)
+// ---------------
+//
+// Dialogs pop up over the current lander and the reply is sent, once the user answered them.
+// If the user cancelled the dialog, the call fails with the DIALOG_CANCELLED message.
+//
+// Ask a yes/no question. Either DIALOG_YES or DIALOG_NO is replied. Example usage:
+//
+//	dialog.confirm::Overwrite the existing configuration?
+
+var DIALOG_CONFIRM string = "dialog.confirm"
+
+// Ask a text with a label and an optional default value. The text is replied. Example usage:
+//
+//	dialog.input::{Host name}localhost
+
+var DIALOG_INPUT string = "dialog.input"
+
+// Ask a secret text with a label, which is not shown while typing. The text is replied. Example usage:
+//
+//	dialog.password::{Root password}
+
+var DIALOG_PASSWORD string = "dialog.password"
+
+// Ask to choose one of the options, separated by "|". The chosen option is replied. Example usage:
+//
+//	dialog.choose::{Architecture}x86_64|aarch64|riscv64
+
+var DIALOG_CHOOSE string = "dialog.choose"
+
+// Answers of the dialogs
+const (
+	DIALOG_YES       = "yes"
+	DIALOG_NO        = "no"
+	DIALOG_CANCELLED = "cancelled by the user"
+)
@@ -0,0 +1,96 @@
+package teaboxlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+/*
+Protocol v2 sends many API calls over one connection, one JSON object per line:
+
+	{"id": 1, "class": "common.progress.set", "value": 42}
+	{"id": 2, "class": "common.list.add", "key": "potato", "value": "Add potatoes"}
+
+Each message gets a reply on its own line, in the same order:
+
+	{"id": 1, "ok": true, "result": "", "error": ""}
+
+The "id" is anything the client likes and is returned as is. The type of the call is taken
+from the JSON type of the "value": a string, a bool, an int (any number) or json (an object
+or an array). The token of the run is sent in the "token" field. The connection stays open,
+until the client closes it.
+
+The first byte of the connection tells the protocol: "{" is v2, anything else is the
+plain text call of TeaboxAPICall.
+*/
+type teaboxAPIMessage struct {
+	Id    json.RawMessage
+	Class string
+	Token string
+	Key   string
+	Value json.RawMessage
+}
+
+// IsAPIMessage returns true, if the data starts as the protocol v2
+func IsAPIMessage(data []byte) bool {
+	return len(data) > 0 && data[0] == '{'
+}
+
+// NewTeaboxAPIMessage parses a line of the protocol v2. The ID of the message is returned,
+// even if the rest of it is wrong, so the reply can still be matched. A payload, which
+// is not of its type, is the error of the call, same as in the plain text.
+func NewTeaboxAPIMessage(data []byte) (json.RawMessage, *TeaboxAPICall, error) {
+	var msg teaboxAPIMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, nil, fmt.Errorf("malformed message: %s", err.Error())
+	}
+	if msg.Class == "" {
+		return msg.Id, nil, fmt.Errorf("message has no class")
+	}
+
+	ac := &TeaboxAPICall{class: strings.ToLower(msg.Class), token: msg.Token, key: msg.Key, datatype: "string", payload: ""}
+	value := bytes.TrimSpace(msg.Value)
+	if len(value) == 0 || string(value) == "null" {
+		return msg.Id, ac, nil
+	}
+
+	// Payload is kept as a string, same as in the plain text calls
+	switch value[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			return msg.Id, nil, fmt.Errorf("malformed value: %s", err.Error())
+		}
+		ac.payload = s
+	case 't', 'f':
+		ac.datatype = "bool"
+		ac.payload = string(value)
+	case '{', '[':
+		ac.datatype = "json"
+		ac.payload = string(value)
+	default:
+		ac.datatype = "int"
+		ac.payload = string(value)
+	}
+
+	ac.err = ac.checkPayload()
+
+	return msg.Id, ac, nil
+}
+
+// NewTeaboxAPIReply returns a line of the protocol v2 reply to a message
+func NewTeaboxAPIReply(id json.RawMessage, result string, err error) []byte {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+
+	reply := map[string]interface{}{"id": id, "ok": err == nil, "result": result, "error": ""}
+	if err != nil {
+		reply["error"] = err.Error()
+	}
+
+	data, _ := json.Marshal(reply) // Nothing here can fail to marshal
+	return append(data, '\n')
+}
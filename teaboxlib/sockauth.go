@@ -0,0 +1,185 @@
+package teaboxlib
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// Policies of the authentication of the socket clients, from the least strict one
+const (
+	AUTH_NONE   = "none"   // Anyone, who can reach the socket
+	AUTH_USER   = "user"   // Processes of the same user as Teabox
+	AUTH_TOKEN  = "token"  // Processes of the same user, sending the token of the run with each call
+	AUTH_STRICT = "strict" // Same as "token", but only the processes, started by Teabox
+)
+
+// IsAuthPolicy returns true, if the policy is known
+func IsAuthPolicy(policy string) bool {
+	switch policy {
+	case AUTH_NONE, AUTH_USER, AUTH_TOKEN, AUTH_STRICT:
+		return true
+	}
+	return false
+}
+
+// TeaboxSocketAuth authenticates the clients of a Unix socket of a run by their peer credentials
+// and by the token of the run.
+type TeaboxSocketAuth struct {
+	policy   string
+	token    string
+	uid      int
+	pid      int
+	onReject func(reason string)
+}
+
+// NewTeaboxSocketAuth constructor. Empty token is never required.
+func NewTeaboxSocketAuth(policy, token string) *TeaboxSocketAuth {
+	return &TeaboxSocketAuth{
+		policy:   policy,
+		token:    token,
+		uid:      os.Getuid(),
+		pid:      os.Getpid(),
+		onReject: func(string) {},
+	}
+}
+
+// SetOnRejectAction is called with the reason, when a client is rejected
+func (tsa *TeaboxSocketAuth) SetOnRejectAction(action func(reason string)) *TeaboxSocketAuth {
+	tsa.onReject = action
+	return tsa
+}
+
+// GetPolicy returns the policy of the authentication, one of AUTH_*
+func (tsa *TeaboxSocketAuth) GetPolicy() string {
+	return tsa.policy
+}
+
+// CheckPeer checks the process on the other side of the connection, before anything is read from it
+func (tsa *TeaboxSocketAuth) CheckPeer(conn net.Conn) error {
+	if tsa.policy == AUTH_NONE {
+		return nil
+	}
+
+	cred, err := GetPeerCredentials(conn)
+	if err != nil {
+		return tsa.reject(fmt.Errorf("rejected a connection with unknown peer: %s", err.Error()))
+	}
+
+	if int(cred.Uid) != tsa.uid {
+		return tsa.reject(fmt.Errorf("rejected a connection from PID %d: UID %d is not the user of Teabox", cred.Pid, cred.Uid))
+	}
+
+	if tsa.policy == AUTH_STRICT {
+		return tsa.checkProcess(int(cred.Pid), int(cred.Uid))
+	}
+
+	return nil
+}
+
+// Check that the process of the peer was started by Teabox. Short-living client (e.g. netcat) may quit,
+// before it is checked, and then it cannot be told, whose it was, so it is rejected as well.
+func (tsa *TeaboxSocketAuth) checkProcess(pid, uid int) error {
+	if !isRunning(pid) {
+		return tsa.reject(fmt.Errorf("rejected a connection from PID %d (UID %d): process is gone and cannot be checked", pid, uid))
+	}
+
+	if !IsDescendant(pid, tsa.pid) {
+		return tsa.reject(fmt.Errorf("rejected a connection from PID %d (UID %d): process was not started by Teabox", pid, uid))
+	}
+
+	return nil
+}
+
+// CheckCall checks the token of the call. Wrong token is always rejected, missing one only if the policy requires it.
+func (tsa *TeaboxSocketAuth) CheckCall(call *TeaboxAPICall) error {
+	if tsa.policy == AUTH_NONE || tsa.token == "" {
+		return nil
+	}
+
+	if call.GetToken() == "" {
+		if tsa.policy == AUTH_TOKEN || tsa.policy == AUTH_STRICT {
+			return tsa.reject(fmt.Errorf("rejected \"%s\" call without a token", call.GetClass()))
+		}
+		return nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(call.GetToken()), []byte(tsa.token)) != 1 {
+		return tsa.reject(fmt.Errorf("rejected \"%s\" call with a wrong token", call.GetClass()))
+	}
+
+	return nil
+}
+
+func (tsa *TeaboxSocketAuth) reject(err error) error {
+	tsa.onReject(err.Error())
+	return err
+}
+
+// GetPeerCredentials returns the credentials of the process on the other side of a Unix socket connection
+func GetPeerCredentials(conn net.Conn) (*unix.Ucred, error) {
+	uconn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return nil, fmt.Errorf("not a Unix socket connection")
+	}
+
+	raw, err := uconn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	var cred *unix.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return nil, err
+	}
+
+	return cred, credErr
+}
+
+// IsDescendant returns true, if the process is the ancestor itself or was started by it, maybe indirectly
+func IsDescendant(pid, ancestor int) bool {
+	for pid > 1 {
+		if pid == ancestor {
+			return true
+		}
+
+		ppid, err := getParentPid(pid)
+		if err != nil {
+			return false
+		}
+		pid = ppid
+	}
+
+	return pid == ancestor
+}
+
+// Check if the process is still there
+func isRunning(pid int) bool {
+	_, err := os.Stat(fmt.Sprintf("/proc/%d", pid))
+	return err == nil
+}
+
+// Get the parent of the process from the /proc
+func getParentPid(pid int) (int, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, err
+	}
+
+	// Command name in the brackets may contain anything, so the fields are after the last one
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("unexpected format of /proc/%d/stat", pid)
+	}
+
+	return strconv.Atoi(fields[1])
+}
@@ -0,0 +1,128 @@
+package teaboxlib
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TeaboxSocketAuthTestSuite struct {
+	suite.Suite
+	pth      string
+	calls    chan *TeaboxAPICall
+	rejected chan string
+}
+
+func (s *TeaboxSocketAuthTestSuite) SetupTest() {
+	s.pth = filepath.Join(s.T().TempDir(), "auth.sock")
+	s.calls = make(chan *TeaboxAPICall, 1)
+	s.rejected = make(chan string, 1)
+}
+
+// Start a server with the authentication and send it a call. Returns the call, if it was accepted.
+func (s *TeaboxSocketAuthTestSuite) send(auth *TeaboxSocketAuth, data string) *TeaboxAPICall {
+	auth.SetOnRejectAction(func(reason string) { s.rejected <- reason })
+	server := NewTeaboxSocketServer().SetAuth(auth).AddGlobalAction(func(call *TeaboxAPICall) string {
+		s.calls <- call
+		return ""
+	})
+	s.NoError(server.Start(s.pth))
+	defer server.Stop()
+
+	conn, err := net.Dial("unix", s.pth)
+	s.NoError(err)
+	_, err = conn.Write([]byte(data))
+	s.NoError(err)
+	s.NoError(conn.(*net.UnixConn).CloseWrite())
+	defer conn.Close()
+
+	select {
+	case call := <-s.calls:
+		return call
+	case <-s.rejected:
+		return nil
+	case <-time.After(5 * time.Second):
+		s.Fail("no call and no rejection")
+		return nil
+	}
+}
+
+// Calls of the same user are accepted, and the token is not part of the class
+func (s *TeaboxSocketAuthTestSuite) TestUser() {
+	call := s.send(NewTeaboxSocketAuth(AUTH_USER, "secret"), "logger.status@secret::Hello")
+	s.NotNil(call)
+	s.Equal("logger.status", call.GetClass())
+	s.Equal("secret", call.GetToken())
+	s.Equal("Hello", call.GetString())
+
+	s.NotNil(s.send(NewTeaboxSocketAuth(AUTH_USER, "secret"), "logger.status::Hello"))
+	s.Nil(s.send(NewTeaboxSocketAuth(AUTH_USER, "secret"), "logger.status@wrong::Hello"))
+}
+
+// Other users are rejected, unless there is no authentication at all
+func (s *TeaboxSocketAuthTestSuite) TestOtherUser() {
+	auth := NewTeaboxSocketAuth(AUTH_USER, "")
+	auth.uid = os.Getuid() + 1
+	s.Nil(s.send(auth, "logger.status::Hello"))
+
+	auth = NewTeaboxSocketAuth(AUTH_NONE, "secret")
+	auth.uid = os.Getuid() + 1
+	s.NotNil(s.send(auth, "logger.status@wrong::Hello"))
+}
+
+// Token is required by the stricter policies
+func (s *TeaboxSocketAuthTestSuite) TestToken() {
+	for _, policy := range []string{AUTH_TOKEN, AUTH_STRICT} {
+		s.Nil(s.send(NewTeaboxSocketAuth(policy, "secret"), "logger.status::Hello"), policy)
+		s.NotNil(s.send(NewTeaboxSocketAuth(policy, "secret"), "logger.status@secret::Hello"), policy)
+	}
+}
+
+// Strict policy accepts only the running processes, started by Teabox. A process, which is not
+// a descendant of Teabox, is rejected even with the right token.
+func (s *TeaboxSocketAuthTestSuite) TestStrict() {
+	auth := NewTeaboxSocketAuth(AUTH_STRICT, "secret")
+	auth.pid = os.Getppid()
+	s.NotNil(s.send(auth, "logger.status@secret::Hello"))
+
+	auth = NewTeaboxSocketAuth(AUTH_STRICT, "secret")
+	auth.pid = os.Getpid() + 1_000_000 // Not an ancestor of anything
+	s.Nil(s.send(auth, "logger.status@secret::Hello"))
+}
+
+// Strict policy rejects a client, which quit before it is checked (e.g. a short netcat call), even
+// with the right token, because its parents cannot be checked anymore. A running descendant is accepted.
+func (s *TeaboxSocketAuthTestSuite) TestRunning() {
+	cmd := exec.Command("/bin/true")
+	s.NoError(cmd.Run())
+	s.False(isRunning(cmd.Process.Pid))
+	s.True(isRunning(os.Getpid()))
+
+	auth := NewTeaboxSocketAuth(AUTH_STRICT, "secret")
+	auth.pid = os.Getppid()
+	s.Error(auth.checkProcess(cmd.Process.Pid, os.Getuid()))
+	s.NoError(auth.checkProcess(os.Getpid(), os.Getuid()))
+}
+
+func (s *TeaboxSocketAuthTestSuite) TestDescendant() {
+	s.True(IsDescendant(os.Getpid(), os.Getpid()))
+	s.True(IsDescendant(os.Getpid(), os.Getppid()))
+	s.True(IsDescendant(os.Getpid(), 1))
+	s.False(IsDescendant(os.Getppid(), os.Getpid()))
+}
+
+func (s *TeaboxSocketAuthTestSuite) TestPolicies() {
+	for _, policy := range []string{AUTH_NONE, AUTH_USER, AUTH_TOKEN, AUTH_STRICT} {
+		s.True(IsAuthPolicy(policy))
+	}
+	s.False(IsAuthPolicy("paranoid"))
+}
+
+func TestTeaboxSocketAuthTestSuite(t *testing.T) {
+	suite.Run(t, new(TeaboxSocketAuthTestSuite))
+}
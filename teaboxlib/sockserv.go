@@ -1,23 +1,34 @@
 package teaboxlib
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
+	"io"
 	"net"
 	"os"
+	"sync"
 	"time"
 )
 
+// API_MESSAGE_MAX_SIZE is the longest line of the protocol v2, e.g. a table of data
+const API_MESSAGE_MAX_SIZE = 0x1000000
+
 type TeaboxSocketListener struct {
 	addr    string
 	conn    net.Listener
+	auth    *TeaboxSocketAuth
+	onError func(class string, err error)
 	actions []func(*TeaboxAPICall) string
+	clients map[net.Conn]bool
+	mtx     sync.Mutex
 }
 
 func NewTeaboxSocketListener(pth string) *TeaboxSocketListener {
 	tsl := new(TeaboxSocketListener)
 	tsl.addr = pth
 	tsl.actions = []func(*TeaboxAPICall) string{}
+	tsl.clients = map[net.Conn]bool{}
 	return tsl
 }
 
@@ -26,6 +37,18 @@ func (tsl *TeaboxSocketListener) AddActions(action ...func(*TeaboxAPICall) strin
 	return tsl
 }
 
+// SetAuth of the clients. Nil accepts everyone.
+func (tsl *TeaboxSocketListener) SetAuth(auth *TeaboxSocketAuth) *TeaboxSocketListener {
+	tsl.auth = auth
+	return tsl
+}
+
+// SetOnErrorAction is called with every call, which could not be done, and why
+func (tsl *TeaboxSocketListener) SetOnErrorAction(action func(class string, err error)) *TeaboxSocketListener {
+	tsl.onError = action
+	return tsl
+}
+
 // Cleanup all the same unix socket addresses prior or after
 func (tsl *TeaboxSocketListener) Cleanup() error {
 	return os.RemoveAll(tsl.addr)
@@ -37,7 +60,14 @@ func (tsl *TeaboxSocketListener) Connect() error {
 	}
 
 	var err error
-	tsl.conn, err = net.Listen("unix", tsl.addr)
+	if tsl.conn, err = net.Listen("unix", tsl.addr); err != nil {
+		return err
+	}
+
+	// Only the user of Teabox and its modules can talk to it
+	if err = os.Chmod(tsl.addr, 0o600); err != nil {
+		_ = tsl.Terminate()
+	}
 	return err
 }
 
@@ -52,31 +82,136 @@ func (tsl *TeaboxSocketListener) Start() error {
 			return err
 		}
 
-		// Go over all registered calls and send them the API instruction calls
-		go func(c net.Conn) {
-			buff := bytes.NewBuffer(nil)
-			chunk := make([]byte, 0xff)
-			for {
-				buffLen, _ := c.Read(chunk)
-				if buffLen > 0 {
-					buff.Write(chunk[:buffLen])
-				} else {
-					break
+		go tsl.serve(bind)
+	}
+
+	return nil
+}
+
+// Serve a connection of a client. The protocol is told by the first byte of it.
+func (tsl *TeaboxSocketListener) serve(c net.Conn) {
+	tsl.mtx.Lock()
+	tsl.clients[c] = true
+	tsl.mtx.Unlock()
+	defer func() {
+		tsl.mtx.Lock()
+		delete(tsl.clients, c)
+		tsl.mtx.Unlock()
+		c.Close()
+	}()
+	if tsl.auth != nil && tsl.auth.CheckPeer(c) != nil {
+		return
+	}
+
+	reader := bufio.NewReader(c)
+	if head, err := reader.Peek(1); err == nil && IsAPIMessage(head) {
+		tsl.serveMessages(reader, c)
+	} else {
+		tsl.serveCall(reader, c)
+	}
+}
+
+// Serve a single plain text call, which is read until the client closes its side
+func (tsl *TeaboxSocketListener) serveCall(reader io.Reader, c net.Conn) {
+	buff, _ := io.ReadAll(reader)
+	call := NewTeaboxAPICall(buff)
+	if tsl.auth != nil && tsl.auth.CheckCall(call) != nil {
+		return
+	}
+
+	// Go over all registered calls and send them the API instruction calls
+	rets, err := tsl.call(call)
+	if err != nil {
+		c.Write([]byte(fmt.Sprintf("%s:error:%s\n", call.GetClass(), err.Error())))
+	}
+	for _, ret := range rets {
+		c.Write([]byte(fmt.Sprintf("%s:%s\n", call.GetClass(), ret)))
+	}
+}
+
+// Serve the messages of the protocol v2, one per line, replying to each of them. The messages are read
+// aside of the calls, so a call, which waits for something (e.g. the user), knows when the client is gone.
+func (tsl *TeaboxSocketListener) serveMessages(reader io.Reader, c net.Conn) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0x10000), API_MESSAGE_MAX_SIZE)
+
+	lines := make(chan []byte, 0x40)
+	closed := make(chan struct{})
+	quit := make(chan struct{})
+	defer close(quit)
+	go func() {
+		defer close(closed)
+		defer close(lines)
+		for scanner.Scan() {
+			if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+				select {
+				case lines <- append([]byte{}, line...):
+				case <-quit:
+					return
 				}
 			}
+		}
+	}()
 
-			call := NewTeaboxAPICall(buff.Bytes())
+	for line := range lines {
+		id, call, err := NewTeaboxAPIMessage(line)
+		if err != nil {
+			tsl.fail("", err)
+		} else {
+			call.setClosed(closed)
+			if tsl.auth != nil {
+				err = tsl.auth.CheckCall(call)
+			}
+		}
 
-			for _, a := range tsl.actions {
-				if ret := a(call); ret != "" {
-					c.Write([]byte(fmt.Sprintf("%s:%s\n", call.GetClass(), ret)))
-				}
+		var result string
+		if err == nil {
+			// Only one action replies to a call, if any
+			var rets []string
+			if rets, err = tsl.call(call); len(rets) > 0 {
+				result = rets[0]
 			}
+		}
 
-		}(bind)
+		if _, err := c.Write(NewTeaboxAPIReply(id, result, err)); err != nil {
+			return
+		}
 	}
 
-	return nil
+	if err := scanner.Err(); err != nil {
+		c.Write(NewTeaboxAPIReply(nil, "", err))
+	}
+}
+
+// Pass the call to all registered actions, returning what they replied, or why none of them did it
+func (tsl *TeaboxSocketListener) call(call *TeaboxAPICall) ([]string, error) {
+	rets := []string{}
+	if call.GetError() == nil {
+		for _, a := range tsl.actions {
+			if ret := a(call); ret != "" {
+				rets = append(rets, ret)
+				call.SetHandled()
+			}
+		}
+	}
+
+	if call.IsHandled() {
+		return rets, nil
+	}
+
+	err := call.GetError()
+	if err == nil {
+		err = fmt.Errorf("unknown API call \"%s\"", call.GetClass())
+	}
+	tsl.fail(call.GetClass(), err)
+
+	return nil, err
+}
+
+func (tsl *TeaboxSocketListener) fail(class string, err error) {
+	if tsl.onError != nil {
+		tsl.onError(class, err)
+	}
 }
 
 func (tsl *TeaboxSocketListener) Terminate() error {
@@ -84,6 +219,13 @@ func (tsl *TeaboxSocketListener) Terminate() error {
 		return err
 	}
 
+	// Clients of the protocol v2 may stay connected, but they are done as well
+	tsl.mtx.Lock()
+	for c := range tsl.clients {
+		c.Close()
+	}
+	tsl.mtx.Unlock()
+
 	tsl.conn = nil
 	return nil
 }
@@ -95,6 +237,8 @@ This server also registers new listeners in Start(path).
 type TeaboxSocketServer struct {
 	mtx           bool
 	listener      *TeaboxSocketListener
+	auth          *TeaboxSocketAuth
+	onError       func(class string, err error)
 	localActions  []func(*TeaboxAPICall) string
 	globalActions []func(*TeaboxAPICall) string
 }
@@ -138,16 +282,31 @@ func (tss *TeaboxSocketServer) AddGlobalAction(action func(*TeaboxAPICall) strin
 	return tss
 }
 
+// SetAuth of the clients for the next start of the server. It is reset, once the server is stopped.
+func (tss *TeaboxSocketServer) SetAuth(auth *TeaboxSocketAuth) *TeaboxSocketServer {
+	tss.auth = auth
+	return tss
+}
+
+// SetOnErrorAction for the next start of the server, which is called with every call that could not
+// be done. It is reset, once the server is stopped.
+func (tss *TeaboxSocketServer) SetOnErrorAction(action func(class string, err error)) *TeaboxSocketServer {
+	tss.onError = action
+	return tss
+}
+
 // Start the Unix socket Server
 func (tss *TeaboxSocketServer) Start(pth string) error {
 	if len(tss.localActions) == 0 && len(tss.globalActions) == 0 {
 		return fmt.Errorf("no any actions were assigned yet")
 	}
-	tss.listener = NewTeaboxSocketListener(pth).AddActions(tss.globalActions...).AddActions(tss.localActions...)
+	tss.listener = NewTeaboxSocketListener(pth).SetAuth(tss.auth).SetOnErrorAction(tss.onError).AddActions(tss.globalActions...).AddActions(tss.localActions...)
 	if err := tss.listener.Cleanup(); err != nil {
+		tss.listener = nil
 		return err
 	}
 	if err := tss.listener.Connect(); err != nil {
+		tss.listener = nil
 		return err
 	}
 
@@ -160,6 +319,8 @@ func (tss *TeaboxSocketServer) Start(pth string) error {
 func (tss *TeaboxSocketServer) Stop() error {
 	defer func() {
 		tss.listener = nil
+		tss.auth = nil
+		tss.onError = nil
 		tss.localActions = []func(*TeaboxAPICall) string{}
 	}()
 	if err := tss.listener.Terminate(); err != nil {
@@ -168,6 +329,14 @@ func (tss *TeaboxSocketServer) Stop() error {
 	return tss.listener.Cleanup()
 }
 
+// GetSocketPath returns the path of the Unix socket, while the server is running
+func (tss *TeaboxSocketServer) GetSocketPath() string {
+	if tss.listener == nil {
+		return ""
+	}
+	return tss.listener.addr
+}
+
 // IsRunning checks if Unix socket server is running
 func (tss *TeaboxSocketServer) IsRunning() bool {
 	return tss.listener != nil
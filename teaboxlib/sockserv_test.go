@@ -0,0 +1,271 @@
+package teaboxlib
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TeaboxSocketServerTestSuite struct {
+	suite.Suite
+	pth    string
+	server *TeaboxSocketServer
+	calls  []*TeaboxAPICall
+	errors []string
+	mtx    sync.Mutex
+}
+
+func (s *TeaboxSocketServerTestSuite) SetupTest() {
+	s.pth = filepath.Join(s.T().TempDir(), "api.sock")
+	s.calls = []*TeaboxAPICall{}
+	s.errors = []string{}
+	s.server = NewTeaboxSocketServer().AddGlobalAction(func(call *TeaboxAPICall) string {
+		s.mtx.Lock()
+		s.calls = append(s.calls, call)
+		s.mtx.Unlock()
+		switch call.GetClass() {
+		case "session.get":
+			return "value of " + call.GetKey()
+		case "field.set.by-label":
+			if call.GetKey() != "Name" {
+				call.SetNotFound(fmt.Errorf("no such field \"%s\"", call.GetKey()))
+				return ""
+			}
+		case "common.progress.set", "common.list.add", "common.checkbox.set", "common.table.add":
+		default:
+			return ""
+		}
+		call.SetHandled()
+		return ""
+	})
+}
+
+// Calls, which reached the action. They are done in the goroutines of the server.
+func (s *TeaboxSocketServerTestSuite) getCalls() []*TeaboxAPICall {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]*TeaboxAPICall{}, s.calls...)
+}
+
+func (s *TeaboxSocketServerTestSuite) TearDownTest() {
+	s.NoError(s.server.Stop())
+}
+
+func (s *TeaboxSocketServerTestSuite) dial() *net.UnixConn {
+	if s.server.GetSocketPath() == "" {
+		s.NoError(s.server.Start(s.pth))
+	}
+	conn, err := net.Dial("unix", s.pth)
+	s.NoError(err)
+	return conn.(*net.UnixConn)
+}
+
+// Send the messages of the protocol v2 over one connection and read the replies
+func (s *TeaboxSocketServerTestSuite) send(lines ...string) []map[string]interface{} {
+	conn := s.dial()
+	defer conn.Close()
+
+	for _, line := range lines {
+		_, err := conn.Write([]byte(line + "\n"))
+		s.NoError(err)
+	}
+	s.NoError(conn.CloseWrite())
+
+	replies := []map[string]interface{}{}
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		reply := map[string]interface{}{}
+		s.NoError(json.Unmarshal(scanner.Bytes(), &reply))
+		replies = append(replies, reply)
+	}
+
+	return replies
+}
+
+// Plain text calls are still one per connection
+func (s *TeaboxSocketServerTestSuite) TestLegacy() {
+	conn := s.dial()
+	_, err := conn.Write([]byte("SESSION.GET::{disk}"))
+	s.NoError(err)
+	s.NoError(conn.CloseWrite())
+	out, err := io.ReadAll(conn)
+	s.NoError(err)
+	conn.Close()
+
+	s.Equal("session.get:value of disk\n", string(out))
+	s.Len(s.getCalls(), 1)
+}
+
+// Plain text calls, which could not be done, are replied with the error
+func (s *TeaboxSocketServerTestSuite) TestLegacyErrors() {
+	for data, reply := range map[string]string{
+		"comon.progress.set:int:42":   "comon.progress.set:error:unknown API call \"comon.progress.set\"\n",
+		"common.progress.set:int:abc": "common.progress.set:error:cannot convert \"abc\" to int\n",
+		"field.set.by-label::{Nmae}x": "field.set.by-label:error:no such field \"Nmae\"\n",
+		"common.progress.set":         ":error:malformed call, expected <CLASS>:[TYPE]:[PAYLOAD]\n",
+		"common.progress.set:int:42":  "",
+	} {
+		conn := s.dial()
+		_, err := conn.Write([]byte(data))
+		s.NoError(err)
+		s.NoError(conn.CloseWrite())
+		out, err := io.ReadAll(conn)
+		s.NoError(err)
+		conn.Close()
+
+		s.Equal(reply, string(out), data)
+	}
+
+	// Wrong payloads are not passed to the actions
+	s.Len(s.getCalls(), 3)
+}
+
+// Many messages are replied in order on the same connection
+func (s *TeaboxSocketServerTestSuite) TestMessages() {
+	replies := s.send(
+		`{"id": 1, "class": "common.progress.set", "value": 42}`,
+		``,
+		`{"id": "two", "class": "session.get", "key": "disk"}`,
+		`{"id": 3, "class": "common.list.add", "key": "potato", "value": "Add: potatoes"}`,
+		`{"id": 4, "class": "common.checkbox.set", "value": true}`,
+		`{"id": 5, "class": "common.table.add", "value": {"name": "vim"}}`,
+	)
+
+	s.Len(replies, 5)
+	s.Equal(float64(1), replies[0]["id"])
+	s.Equal(true, replies[0]["ok"])
+	s.Equal("two", replies[1]["id"])
+	s.Equal("value of disk", replies[1]["result"])
+
+	calls := s.getCalls()
+	s.Len(calls, 5)
+	s.Equal("int", calls[0].GetType())
+	s.Equal(42, calls[0].GetInt())
+	s.Equal("string", calls[2].GetType())
+	s.Equal("potato", calls[2].GetKey())
+	s.Equal("Add: potatoes", calls[2].GetString())
+	s.True(calls[3].GetBool())
+	s.Equal("json", calls[4].GetType())
+	s.Equal(`{"name": "vim"}`, calls[4].GetValue())
+}
+
+// Messages, which could not be done, are replied with the error, and are logged in strict mode
+func (s *TeaboxSocketServerTestSuite) TestErrors() {
+	s.server.SetOnErrorAction(func(class string, err error) {
+		s.errors = append(s.errors, class+": "+err.Error())
+	})
+	replies := s.send(
+		`{"id": 1, "class": "comon.progress.set", "value": 42}`,
+		`{"id": 2, "class": "common.progress.set", "value": 4.2}`,
+		`{"id": 3, "class": "field.set.by-label", "key": "Nmae", "value": "x"}`,
+		`{"id": 4, "class": "field.set.by-label", "key": "Name", "value": "x"}`,
+	)
+
+	s.Len(replies, 4)
+	for i, e := range []string{`unknown API call "comon.progress.set"`, `cannot convert "4.2" to int`, `no such field "Nmae"`} {
+		s.Equal(false, replies[i]["ok"])
+		s.Equal(e, replies[i]["error"])
+	}
+	s.Equal(true, replies[3]["ok"])
+	s.Equal([]string{`comon.progress.set: unknown API call "comon.progress.set"`,
+		`common.progress.set: cannot convert "4.2" to int`, `field.set.by-label: no such field "Nmae"`}, s.errors)
+}
+
+// Field, which is not on one form, does not hide the error of the form, which has it
+func (s *TeaboxSocketServerTestSuite) TestNotFound() {
+	s.server.AddGlobalAction(func(call *TeaboxAPICall) string {
+		if call.GetClass() == "field.set.by-label" && call.GetKey() == "Packages" {
+			call.SetError(fmt.Errorf("table data is not a valid JSON"))
+		}
+		return ""
+	})
+	replies := s.send(
+		`{"id": 1, "class": "field.set.by-label", "key": "Packages", "value": "[{"}`,
+		`{"id": 2, "class": "field.set.by-label", "key": "Pakages", "value": "[]"}`,
+	)
+
+	s.Len(replies, 2)
+	s.Equal("table data is not a valid JSON", replies[0]["error"])
+	s.Equal(`no such field "Pakages"`, replies[1]["error"])
+}
+
+// Broken message is replied with an error, but does not break the connection
+func (s *TeaboxSocketServerTestSuite) TestMalformed() {
+	replies := s.send(`{"id": 1, "class": `, `{"id": 2, "value": 1}`, `{"id": 3, "class": "session.get"}`)
+
+	s.Len(replies, 3)
+	s.Nil(replies[0]["id"])
+	s.Equal(false, replies[0]["ok"])
+	s.Contains(replies[0]["error"], "malformed")
+	s.Equal(float64(2), replies[1]["id"])
+	s.Equal(false, replies[1]["ok"])
+	s.Equal(true, replies[2]["ok"])
+	s.Len(s.getCalls(), 1)
+}
+
+// Token of the run is checked for each message
+func (s *TeaboxSocketServerTestSuite) TestToken() {
+	s.server.SetAuth(NewTeaboxSocketAuth(AUTH_TOKEN, "secret"))
+	replies := s.send(`{"id": 1, "class": "session.get"}`, `{"id": 2, "class": "session.get", "token": "secret"}`)
+
+	s.Len(replies, 2)
+	s.Equal(false, replies[0]["ok"])
+	s.Contains(replies[0]["error"], "without a token")
+	s.Equal(true, replies[1]["ok"])
+	s.Len(s.getCalls(), 1)
+}
+
+// Connection of a client is closed, once the server is stopped
+func (s *TeaboxSocketServerTestSuite) TestStop() {
+	conn := s.dial()
+	defer conn.Close()
+	_, err := conn.Write([]byte(`{"id": 1, "class": "session.get"}` + "\n"))
+	s.NoError(err)
+	reader := bufio.NewReader(conn)
+	_, err = reader.ReadBytes('\n')
+	s.NoError(err)
+
+	s.NoError(s.server.Stop())
+	_, err = reader.ReadBytes('\n')
+	s.ErrorIs(err, io.EOF)
+
+	s.NoError(s.server.Start(s.pth))
+}
+
+// Call, which waits, learns that its client is gone
+func (s *TeaboxSocketServerTestSuite) TestClosed() {
+	waiting := make(chan struct{})
+	gone := make(chan struct{})
+	s.server.AddGlobalAction(func(call *TeaboxAPICall) string {
+		if call.GetClass() == "dialog.confirm" {
+			close(waiting)
+			<-call.GetClosed()
+			close(gone)
+		}
+		return ""
+	})
+
+	conn := s.dial()
+	_, err := conn.Write([]byte(`{"id": 1, "class": "dialog.confirm", "value": "Sure?"}` + "\n"))
+	s.NoError(err)
+	<-waiting
+	s.NoError(conn.Close())
+
+	select {
+	case <-gone:
+	case <-time.After(2 * time.Second):
+		s.Fail("call was not told that its connection is closed")
+	}
+}
+
+func TestTeaboxSocketServerTestSuite(t *testing.T) {
+	suite.Run(t, new(TeaboxSocketServerTestSuite))
+}
@@ -0,0 +1,348 @@
+package teaboxclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gitlab.com/isbm/teabox/teaboxlib"
+)
+
+// # Loader (init)
+
+// InitProgress sets the progress of the loader, in percents
+func (tc *TeaboxClient) InitProgress(percent int) error {
+	return tc.call(teaboxlib.INIT_SET_PROGRESS, "", percent)
+}
+
+// InitNextProgress increments the progress of the loader by one allocated step
+func (tc *TeaboxClient) InitNextProgress() error {
+	return tc.call(teaboxlib.INIT_INC_PROGRESS, "", nil)
+}
+
+// InitAllocateProgress allocates the steps of the progress of the loader
+func (tc *TeaboxClient) InitAllocateProgress(steps int) error {
+	return tc.call(teaboxlib.INIT_ALLOC_PROGRESS, "", steps)
+}
+
+// InitStatus sets the status of the loader
+func (tc *TeaboxClient) InitStatus(status string) error {
+	return tc.call(teaboxlib.INIT_SET_STATUS, "", status)
+}
+
+// InitReset resets the loader
+func (tc *TeaboxClient) InitReset() error {
+	return tc.call(teaboxlib.INIT_RESET, "", nil)
+}
+
+// # Logger (lander)
+
+// LoggerStatus sets the status of the logger
+func (tc *TeaboxClient) LoggerStatus(status string) error {
+	return tc.call(teaboxlib.LOGGER_STATUS, "", status)
+}
+
+// LoggerTitle sets the title of the logger
+func (tc *TeaboxClient) LoggerTitle(title string) error {
+	return tc.call(teaboxlib.LOGGER_TITLE, "", title)
+}
+
+// # List (lander)
+
+// ListTitle sets the title of the list
+func (tc *TeaboxClient) ListTitle(title string) error {
+	return tc.call(teaboxlib.LIST_TITLE, "", title)
+}
+
+// ListStatus sets the status of the list
+func (tc *TeaboxClient) ListStatus(status string) error {
+	return tc.call(teaboxlib.LIST_STATUS, "", status)
+}
+
+// ListHeader sets the labels of the columns of the list
+func (tc *TeaboxClient) ListHeader(labels ...string) error {
+	return tc.call(teaboxlib.LIST_HEADER, "", labels)
+}
+
+// ListRows adds the rows to the bottom of the list
+func (tc *TeaboxClient) ListRows(rows [][]string) error {
+	return tc.call(teaboxlib.LIST_ADD, "", rows)
+}
+
+// ListClear removes all rows from the list
+func (tc *TeaboxClient) ListClear() error {
+	return tc.call(teaboxlib.LIST_CLEAR, "", nil)
+}
+
+// ListValue sets the column, starting from 1, which value is taken from the selected row
+func (tc *TeaboxClient) ListValue(column int) error {
+	return tc.call(teaboxlib.LIST_VALUE, "", column)
+}
+
+// # Terminal (lander)
+
+// TerminalTitle sets the title of the terminal
+func (tc *TeaboxClient) TerminalTitle(title string) error {
+	return tc.call(teaboxlib.TERMINAL_TITLE, "", title)
+}
+
+// TerminalStatus sets the status of the terminal
+func (tc *TeaboxClient) TerminalStatus(status string) error {
+	return tc.call(teaboxlib.TERMINAL_STATUS, "", status)
+}
+
+// # Common (lander)
+
+// ProgressEvent sets the event above the progress bar
+func (tc *TeaboxClient) ProgressEvent(event string) error {
+	return tc.call(teaboxlib.COMMON_PROGRESS_EVENT, "", event)
+}
+
+// AllocateProgress allocates the steps of the progress
+func (tc *TeaboxClient) AllocateProgress(steps int) error {
+	return tc.call(teaboxlib.COMMON_PROGRESS_ALLOCATE, "", steps)
+}
+
+// NextProgress increments the progress by one allocated step
+func (tc *TeaboxClient) NextProgress() error {
+	return tc.call(teaboxlib.COMMON_PROGRESS_NEXT, "", nil)
+}
+
+// SetProgress sets the progress, in percents
+func (tc *TeaboxClient) SetProgress(percent int) error {
+	return tc.call(teaboxlib.COMMON_PROGRESS_SET, "", percent)
+}
+
+// LookupPrefix sets the prefix of the output lines, which are taken as events
+func (tc *TeaboxClient) LookupPrefix(prefix string) error {
+	return tc.call(teaboxlib.COMMON_LOOKUP_PREFIX, "", prefix)
+}
+
+// LookupGlob sets the shell pattern of the output lines, which are taken as events
+func (tc *TeaboxClient) LookupGlob(glob string) error {
+	return tc.call(teaboxlib.COMMON_LOOKUP_GLOB, "", glob)
+}
+
+// LookupRegex sets the regular expression of the output lines, which are taken as events
+func (tc *TeaboxClient) LookupRegex(regex string) error {
+	return tc.call(teaboxlib.COMMON_LOOKUP_REGEX, "", regex)
+}
+
+// ListAdd adds an item to the checklist
+func (tc *TeaboxClient) ListAdd(id, label string) error {
+	return tc.call(teaboxlib.COMMON_LIST_ADD_ITEM, id, label)
+}
+
+// ListComplete completes an item of the checklist
+func (tc *TeaboxClient) ListComplete(id string) error {
+	return tc.call(teaboxlib.COMMON_LIST_COMPLETE_ITEM, "", id)
+}
+
+// ListReset resets all items of the checklist to the "todo" state
+func (tc *TeaboxClient) ListReset() error {
+	return tc.call(teaboxlib.COMMON_LIST_RESET, "", nil)
+}
+
+// InfoAdd adds a text to the info area
+func (tc *TeaboxClient) InfoAdd(text string) error {
+	return tc.call(teaboxlib.COMMON_INFO_ADD, "", text)
+}
+
+// InfoSet sets the text of the info area
+func (tc *TeaboxClient) InfoSet(text string) error {
+	return tc.call(teaboxlib.COMMON_INFO_SET, "", text)
+}
+
+// SetTitle sets the title
+func (tc *TeaboxClient) SetTitle(title string) error {
+	return tc.call(teaboxlib.COMMON_TITLE, "", title)
+}
+
+// Reset everything on the lander
+func (tc *TeaboxClient) Reset() error {
+	return tc.call(teaboxlib.COMMON_RESET, "", nil)
+}
+
+// # Form (any)
+//
+// The value of a field is a string, or a bool for a toggle. The options of a dropdown or
+// a list are separated by "|".
+
+// SetField sets the value of a field by its label
+func (tc *TeaboxClient) SetField(label string, value interface{}) error {
+	return tc.call(teaboxlib.FORM_SET_BY_LABEL, label, value)
+}
+
+// SetFieldByOrd sets the value of a field by its order, starting from 0
+func (tc *TeaboxClient) SetFieldByOrd(ord int, value interface{}) error {
+	return tc.call(teaboxlib.FORM_SET_BY_ORD, strconv.Itoa(ord), value)
+}
+
+// AddField adds the value to the value of a field by its label
+func (tc *TeaboxClient) AddField(label string, value interface{}) error {
+	return tc.call(teaboxlib.FORM_ADD_BY_LABEL, label, value)
+}
+
+// AddFieldByOrd adds the value to the value of a field by its order, starting from 0
+func (tc *TeaboxClient) AddFieldByOrd(ord int, value interface{}) error {
+	return tc.call(teaboxlib.FORM_ADD_BY_ORD, strconv.Itoa(ord), value)
+}
+
+// ResetField clears the value of a field by its label
+func (tc *TeaboxClient) ResetField(label string) error {
+	return tc.call(teaboxlib.FORM_CLR_BY_LABEL, label, nil)
+}
+
+// ResetFieldByOrd clears the value of a field by its order, starting from 0
+func (tc *TeaboxClient) ResetFieldByOrd(ord int) error {
+	return tc.call(teaboxlib.FORM_CLR_BY_ORD, strconv.Itoa(ord), nil)
+}
+
+// AddTable adds the rows to the bottom of a table by its order, starting from 0
+func (tc *TeaboxClient) AddTable(ord int, rows [][]string) error {
+	return tc.call(teaboxlib.FORM_ADD_TABLE_BY_ORD, strconv.Itoa(ord), rows)
+}
+
+// SetTable replaces the rows of a table by its order, starting from 0
+func (tc *TeaboxClient) SetTable(ord int, rows [][]string) error {
+	return tc.call(teaboxlib.FORM_SET_TABLE_BY_ORD, strconv.Itoa(ord), rows)
+}
+
+// ClearTable removes all rows of a table by its order, starting from 0
+func (tc *TeaboxClient) ClearTable(ord int) error {
+	return tc.call(teaboxlib.FORM_CLR_TABLE_BY_ORD, strconv.Itoa(ord), nil)
+}
+
+// HideField hides a field by its label. Its argument is not passed to the command.
+func (tc *TeaboxClient) HideField(label string) error {
+	return tc.call(teaboxlib.FORM_HIDE_BY_LABEL, label, nil)
+}
+
+// HideFieldByOrd hides a field by its order, starting from 0
+func (tc *TeaboxClient) HideFieldByOrd(ord int) error {
+	return tc.call(teaboxlib.FORM_HIDE_BY_ORD, strconv.Itoa(ord), nil)
+}
+
+// ShowField shows a hidden field by its label
+func (tc *TeaboxClient) ShowField(label string) error {
+	return tc.call(teaboxlib.FORM_SHOW_BY_LABEL, label, nil)
+}
+
+// ShowFieldByOrd shows a hidden field by its order, starting from 0
+func (tc *TeaboxClient) ShowFieldByOrd(ord int) error {
+	return tc.call(teaboxlib.FORM_SHOW_BY_ORD, strconv.Itoa(ord), nil)
+}
+
+// DisableField disables a field by its label. Its argument is not passed to the command.
+func (tc *TeaboxClient) DisableField(label string) error {
+	return tc.call(teaboxlib.FORM_DISABLE_BY_LABEL, label, nil)
+}
+
+// DisableFieldByOrd disables a field by its order, starting from 0
+func (tc *TeaboxClient) DisableFieldByOrd(ord int) error {
+	return tc.call(teaboxlib.FORM_DISABLE_BY_ORD, strconv.Itoa(ord), nil)
+}
+
+// EnableField enables a disabled field by its label
+func (tc *TeaboxClient) EnableField(label string) error {
+	return tc.call(teaboxlib.FORM_ENABLE_BY_LABEL, label, nil)
+}
+
+// EnableFieldByOrd enables a disabled field by its order, starting from 0
+func (tc *TeaboxClient) EnableFieldByOrd(ord int) error {
+	return tc.call(teaboxlib.FORM_ENABLE_BY_ORD, strconv.Itoa(ord), nil)
+}
+
+// GetField returns the current value of a field by its label, which is "true" or "false" for a toggle.
+// Secret fields return only JOB_SECRET_MASK, if they are set.
+func (tc *TeaboxClient) GetField(label string) (string, error) {
+	return tc.Call(teaboxlib.FORM_GET_BY_LABEL, label, nil)
+}
+
+// GetFieldByOrd returns the current value of a field by its order, starting from 0
+func (tc *TeaboxClient) GetFieldByOrd(ord int) (string, error) {
+	return tc.Call(teaboxlib.FORM_GET_BY_ORD, strconv.Itoa(ord), nil)
+}
+
+// DumpForms returns the current values of all forms of the module, in the order of the commands
+func (tc *TeaboxClient) DumpForms() ([]*TeaboxForm, error) {
+	data, err := tc.Call(teaboxlib.FORM_DUMP, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	dump := []formDump{}
+	if err := json.Unmarshal([]byte(data), &dump); err != nil {
+		return nil, fmt.Errorf("%s: malformed result: %s", teaboxlib.FORM_DUMP, err.Error())
+	}
+
+	forms := []*TeaboxForm{}
+	for _, f := range dump {
+		forms = append(forms, &TeaboxForm{title: f.Title, fields: f.Fields})
+	}
+	return forms, nil
+}
+
+// # Session (any)
+
+// SessionSet sets a value to the session of the module
+func (tc *TeaboxClient) SessionSet(key string, value interface{}) error {
+	return tc.call(teaboxlib.SESSION_SET, key, value)
+}
+
+// SessionGet returns a value from the session of the module, or an empty string, if there is none
+func (tc *TeaboxClient) SessionGet(key string) (string, error) {
+	return tc.Call(teaboxlib.SESSION_GET, key, nil)
+}
+
+// SessionKeys returns the keys of the session of the module
+func (tc *TeaboxClient) SessionKeys() ([]string, error) {
+	keys, err := tc.Call(teaboxlib.SESSION_KEYS, "", nil)
+	if err != nil || keys == "" {
+		return []string{}, err
+	}
+
+	return strings.Split(keys, ","), nil
+}
+
+// SessionDelete deletes a value from the session of the module
+func (tc *TeaboxClient) SessionDelete(key string) error {
+	return tc.call(teaboxlib.SESSION_DELETE, "", key)
+}
+
+// SessionFlush empties the session of the module
+func (tc *TeaboxClient) SessionFlush() error {
+	return tc.call(teaboxlib.SESSION_FLUSH, "", nil)
+}
+
+// # Dialogs (any)
+
+// Confirm asks the user a yes/no question and waits for the answer. If the user cancelled it,
+// the error is a *TeaboxCallError, which IsCancelled tells.
+func (tc *TeaboxClient) Confirm(question string) (bool, error) {
+	answer, err := tc.Call(teaboxlib.DIALOG_CONFIRM, "", question)
+	return answer == teaboxlib.DIALOG_YES, err
+}
+
+// Input asks the user a text with a label, which is pre-filled with the value, and waits for the answer
+func (tc *TeaboxClient) Input(label, value string) (string, error) {
+	return tc.Call(teaboxlib.DIALOG_INPUT, label, value)
+}
+
+// Password asks the user a secret text with a label, which is not shown while typing, and waits for the answer
+func (tc *TeaboxClient) Password(label string) (string, error) {
+	return tc.Call(teaboxlib.DIALOG_PASSWORD, label, "")
+}
+
+// Choose asks the user to choose one of the options with a label, and waits for the answer
+func (tc *TeaboxClient) Choose(label string, options ...string) (string, error) {
+	return tc.Call(teaboxlib.DIALOG_CHOOSE, label, strings.Join(options, "|"))
+}
+
+// IsCancelled tells, if the error is of a dialog, which the user cancelled
+func IsCancelled(err error) bool {
+	var callErr *TeaboxCallError
+	return errors.As(err, &callErr) && callErr.GetMessage() == teaboxlib.DIALOG_CANCELLED
+}
@@ -0,0 +1,196 @@
+/*
+Package teaboxclient is a client of the Teabox socket API for the modules, written in Go.
+
+The client finds the socket and the token of the run in the environment, which Teabox passes
+to the commands of a module, and talks the protocol v2 over one connection:
+
+	client, err := teaboxclient.NewTeaboxClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	client.SetTitle("Potato Preparation")
+	client.ListAdd("water", "Boil water")
+	client.SetProgress(42)
+
+Each call waits for its reply, so an error tells what went wrong, e.g. a field, which does not exist.
+*/
+package teaboxclient
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"gitlab.com/isbm/teabox/teaboxlib"
+)
+
+// TeaboxClient of the socket API of a run of the module
+type TeaboxClient struct {
+	socket string
+	token  string
+	conn   net.Conn
+	reader *bufio.Reader
+	id     int
+	mtx    sync.Mutex
+}
+
+// TeaboxCallError is returned, when Teabox could not do the call, unlike an error of the connection
+type TeaboxCallError struct {
+	class   string
+	message string
+}
+
+func (tce *TeaboxCallError) Error() string {
+	return fmt.Sprintf("%s: %s", tce.class, tce.message)
+}
+
+// GetClass of the call, which could not be done
+func (tce *TeaboxCallError) GetClass() string {
+	return tce.class
+}
+
+// GetMessage tells why the call could not be done
+func (tce *TeaboxCallError) GetMessage() string {
+	return tce.message
+}
+
+// TeaboxForm is a form of the module with the current values of its fields
+type TeaboxForm struct {
+	title  string
+	fields map[string]interface{}
+}
+
+// GetTitle returns the title of the command of the form
+func (tf *TeaboxForm) GetTitle() string {
+	return tf.title
+}
+
+// GetFields returns the values of the fields by their labels. The value of a toggle is a bool,
+// and of any other field a string.
+func (tf *TeaboxForm) GetFields() map[string]interface{} {
+	return tf.fields
+}
+
+// Form in the result of form.dump
+type formDump struct {
+	Title  string
+	Fields map[string]interface{}
+}
+
+// Reply of the protocol v2
+type clientReply struct {
+	Id     int
+	Ok     bool
+	Result string
+	Error  string
+}
+
+// NewTeaboxClient constructor. The socket and the token of the run are taken from the environment.
+func NewTeaboxClient() (*TeaboxClient, error) {
+	socket := os.Getenv(teaboxlib.ENV_SOCKET)
+	if socket == "" {
+		return nil, fmt.Errorf("no socket in %s environment variable, is the module started by Teabox?", teaboxlib.ENV_SOCKET)
+	}
+
+	return NewTeaboxClientAt(socket, os.Getenv(teaboxlib.ENV_TOKEN)), nil
+}
+
+// NewTeaboxClientAt constructor, which talks to the given socket with the given token. Token can be empty.
+func NewTeaboxClientAt(socket, token string) *TeaboxClient {
+	return &TeaboxClient{socket: socket, token: token}
+}
+
+// GetSocketPath returns the path of the socket, which the client talks to
+func (tc *TeaboxClient) GetSocketPath() string {
+	return tc.socket
+}
+
+// Close the connection. The next call connects again.
+func (tc *TeaboxClient) Close() error {
+	tc.mtx.Lock()
+	defer tc.mtx.Unlock()
+
+	return tc.disconnect()
+}
+
+func (tc *TeaboxClient) disconnect() error {
+	if tc.conn == nil {
+		return nil
+	}
+
+	err := tc.conn.Close()
+	tc.conn, tc.reader = nil, nil
+	return err
+}
+
+// Call the API with a class, a key and a value, and return the result of the call.
+// Key can be empty, and value can be nil. The type of the call is the type of the value:
+// a string, a bool, an int, or anything else, which is sent as JSON. If Teabox could not
+// do the call, the error is *TeaboxCallError.
+func (tc *TeaboxClient) Call(class, key string, value interface{}) (string, error) {
+	tc.mtx.Lock()
+	defer tc.mtx.Unlock()
+
+	if tc.conn == nil {
+		conn, err := net.Dial("unix", tc.socket)
+		if err != nil {
+			return "", err
+		}
+		tc.conn, tc.reader = conn, bufio.NewReader(conn)
+	}
+
+	tc.id++
+	msg := map[string]interface{}{"id": tc.id, "class": class}
+	if key != "" {
+		msg["key"] = key
+	}
+	if value != nil {
+		msg["value"] = value
+	}
+	if tc.token != "" {
+		msg["token"] = tc.token
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", class, err.Error())
+	}
+
+	// Connection is broken after a failure, so it is dropped and the next call connects again
+	if _, err := tc.conn.Write(append(data, '\n')); err != nil {
+		_ = tc.disconnect()
+		return "", err
+	}
+
+	line, err := tc.reader.ReadBytes('\n')
+	if err != nil {
+		_ = tc.disconnect()
+		return "", fmt.Errorf("%s: no reply: %s", class, err.Error())
+	}
+
+	var reply clientReply
+	if err := json.Unmarshal(line, &reply); err != nil {
+		_ = tc.disconnect()
+		return "", fmt.Errorf("%s: malformed reply: %s", class, err.Error())
+	}
+	if reply.Id != tc.id {
+		_ = tc.disconnect()
+		return "", fmt.Errorf("%s: reply to another call", class)
+	}
+	if !reply.Ok {
+		return "", &TeaboxCallError{class: class, message: reply.Error}
+	}
+
+	return reply.Result, nil
+}
+
+// Call the API, when there is no result
+func (tc *TeaboxClient) call(class, key string, value interface{}) error {
+	_, err := tc.Call(class, key, value)
+	return err
+}
@@ -0,0 +1,184 @@
+package teaboxclient
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/isbm/teabox/teaboxlib"
+)
+
+type TeaboxClientTestSuite struct {
+	suite.Suite
+	server *TeaboxFakeServer
+	client *TeaboxClient
+}
+
+func (s *TeaboxClientTestSuite) SetupTest() {
+	var err error
+	s.server, err = NewTeaboxFakeServer()
+	s.NoError(err)
+	s.client = s.server.GetClient()
+}
+
+func (s *TeaboxClientTestSuite) TearDownTest() {
+	s.NoError(s.client.Close())
+	s.NoError(s.server.Stop())
+}
+
+// Typed calls are sent with the types of their values
+func (s *TeaboxClientTestSuite) TestCalls() {
+	s.NoError(s.client.SetTitle("Potatoes"))
+	s.NoError(s.client.AllocateProgress(3))
+	s.NoError(s.client.ListAdd("water", "Boil: \"hot\" water\n"))
+	s.NoError(s.client.NextProgress())
+	s.NoError(s.client.SetField("Verbose", true))
+	s.NoError(s.client.SetTable(1, [][]string{{"vim", "9.0"}}))
+
+	calls := s.server.GetCalls()
+	s.Len(calls, 6)
+	s.Equal(teaboxlib.COMMON_TITLE, calls[0].GetClass())
+	s.Equal("Potatoes", calls[0].GetString())
+	s.Equal(3, calls[1].GetInt())
+	s.Equal("water", calls[2].GetKey())
+	s.Equal("Boil: \"hot\" water\n", calls[2].GetString())
+	s.Equal(teaboxlib.COMMON_PROGRESS_NEXT, calls[3].GetClass())
+	s.True(calls[4].GetBool())
+	s.Equal("Verbose", calls[4].GetKey())
+	s.Equal(1, calls[5].GetKeyAsInt())
+	s.Equal("json", calls[5].GetType())
+	s.Equal(`[["vim","9.0"]]`, calls[5].GetValue())
+}
+
+func (s *TeaboxClientTestSuite) TestSession() {
+	s.NoError(s.client.SessionSet("name", "John Smith"))
+	s.NoError(s.client.SessionSet("age", 42))
+
+	name, err := s.client.SessionGet("name")
+	s.NoError(err)
+	s.Equal("John Smith", name)
+	s.Equal("42", s.server.GetSession("age"))
+
+	keys, err := s.client.SessionKeys()
+	s.NoError(err)
+	s.Equal([]string{"age", "name"}, keys)
+
+	s.NoError(s.client.SessionDelete("name"))
+	name, err = s.client.SessionGet("name")
+	s.NoError(err)
+	s.Equal("", name)
+
+	s.NoError(s.client.SessionFlush())
+	keys, err = s.client.SessionKeys()
+	s.NoError(err)
+	s.Empty(keys)
+}
+
+// Fields are read back, as they were set
+func (s *TeaboxClientTestSuite) TestFields() {
+	s.server.SetFields("Name", "Verbose", "Notes")
+
+	s.NoError(s.client.SetField("Name", "John Smith"))
+	s.NoError(s.client.SetFieldByOrd(1, true))
+	name, err := s.client.GetFieldByOrd(0)
+	s.NoError(err)
+	s.Equal("John Smith", name)
+	verbose, err := s.client.GetField("Verbose")
+	s.NoError(err)
+	s.Equal("true", verbose)
+	s.Equal(true, s.server.GetField("Verbose"))
+	_, err = s.client.GetField("Nmae")
+	s.EqualError(err, `field.get.by-label: no such field "Nmae"`)
+
+	s.NoError(s.client.HideField("Notes"))
+	s.NoError(s.client.DisableFieldByOrd(0))
+	s.NoError(s.client.EnableField("Name"))
+	s.NoError(s.client.ShowFieldByOrd(2))
+	s.EqualError(s.client.HideFieldByOrd(3), `field.hide.by-ord: no such field "3"`)
+	s.Len(s.server.GetCallsOf(teaboxlib.FORM_DISABLE_BY_ORD), 1)
+	s.Equal("2", s.server.GetCallsOf(teaboxlib.FORM_SHOW_BY_ORD)[0].GetKey())
+
+	forms, err := s.client.DumpForms()
+	s.NoError(err)
+	s.Len(forms, 1)
+	s.Equal("fake", forms[0].GetTitle())
+	s.Equal(map[string]interface{}{"Name": "John Smith", "Verbose": true}, forms[0].GetFields())
+}
+
+// Dialogs are answered, as the user would
+func (s *TeaboxClientTestSuite) TestDialogs() {
+	s.server.SetAnswer(teaboxlib.DIALOG_CONFIRM, teaboxlib.DIALOG_YES).
+		SetAnswer(teaboxlib.DIALOG_PASSWORD, "secret").
+		SetError(teaboxlib.DIALOG_INPUT, errors.New(teaboxlib.DIALOG_CANCELLED))
+
+	yes, err := s.client.Confirm("Overwrite?")
+	s.NoError(err)
+	s.True(yes)
+	password, err := s.client.Password("Root password")
+	s.NoError(err)
+	s.Equal("secret", password)
+	arch, err := s.client.Choose("Architecture", "x86_64", "aarch64")
+	s.NoError(err)
+	s.Equal("x86_64", arch)
+	s.Equal("x86_64|aarch64", s.server.GetCallsOf(teaboxlib.DIALOG_CHOOSE)[0].GetString())
+
+	_, err = s.client.Input("Host name", "localhost")
+	s.True(IsCancelled(err))
+	s.False(IsCancelled(fmt.Errorf("no reply")))
+	s.EqualError(err, "dialog.input: cancelled by the user")
+}
+
+// Calls, which could not be done, are returned as errors
+func (s *TeaboxClientTestSuite) TestErrors() {
+	s.server.SetFields("Name", "Verbose").SetError(teaboxlib.LIST_ADD, fmt.Errorf("no list"))
+
+	s.NoError(s.client.SetField("Name", "x"))
+	s.NoError(s.client.SetFieldByOrd(1, false))
+	s.EqualError(s.client.SetField("Nmae", "x"), `field.set.by-label: no such field "Nmae"`)
+	s.EqualError(s.client.ResetFieldByOrd(2), `field.reset.by-ord: no such field "2"`)
+	s.EqualError(s.client.ListRows([][]string{{"a"}}), "list.add: no list")
+	_, err := s.client.Call("comon.progress.set", "", 1)
+	s.EqualError(err, `comon.progress.set: unknown API call "comon.progress.set"`)
+	var callErr *TeaboxCallError
+	s.ErrorAs(err, &callErr)
+	s.Equal("comon.progress.set", callErr.GetClass())
+
+	// Connection is still fine
+	s.NoError(s.client.SetProgress(42))
+	s.Len(s.server.GetCallsOf(teaboxlib.COMMON_PROGRESS_SET), 1)
+}
+
+// Socket and token are found in the environment
+func (s *TeaboxClientTestSuite) TestEnv() {
+	s.T().Setenv(teaboxlib.ENV_SOCKET, "")
+	_, err := NewTeaboxClient()
+	s.Error(err)
+
+	for _, env := range s.server.GetEnv() {
+		if key, value, ok := strings.Cut(env, "="); ok {
+			s.T().Setenv(key, value)
+		}
+	}
+	client, err := NewTeaboxClient()
+	s.NoError(err)
+	defer client.Close()
+	s.Equal(s.server.GetSocketPath(), client.GetSocketPath())
+	s.NoError(client.LoggerStatus("Hello"))
+}
+
+// Client connects again, once the server is back
+func (s *TeaboxClientTestSuite) TestReconnect() {
+	s.NoError(s.client.LoggerTitle("One"))
+	s.NoError(s.server.server.Stop())
+	s.Error(s.client.LoggerTitle("Two"))
+
+	s.NoError(s.server.server.Start(s.client.GetSocketPath()))
+	s.NoError(s.client.LoggerTitle("Three"))
+	s.Len(s.server.GetCalls(), 2)
+}
+
+func TestTeaboxClientTestSuite(t *testing.T) {
+	suite.Run(t, new(TeaboxClientTestSuite))
+}
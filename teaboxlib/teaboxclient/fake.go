@@ -0,0 +1,279 @@
+package teaboxclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gitlab.com/isbm/teabox/teaboxlib"
+)
+
+/*
+TeaboxFakeServer is an in-process Teabox socket server for the unit tests of a module.
+It accepts every call, which Teabox knows, keeps the session and records the calls,
+so the test can check what the module did:
+
+	server, err := teaboxclient.NewTeaboxFakeServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+
+	runModule(server.GetClient())
+	calls := server.GetCalls()
+
+The server runs the same protocols as Teabox, so a module, which uses the environment,
+can also be started with server.GetEnv().
+*/
+type TeaboxFakeServer struct {
+	dir     string
+	server  *teaboxlib.TeaboxSocketServer
+	fields  []string
+	values  map[string]interface{}
+	session map[string]string
+	errors  map[string]error
+	answers map[string]string
+	calls   []*teaboxlib.TeaboxAPICall
+	mtx     sync.Mutex
+}
+
+// Classes of the API, which the fake server accepts
+var fakeClasses = []string{
+	teaboxlib.INIT_SET_PROGRESS, teaboxlib.INIT_INC_PROGRESS, teaboxlib.INIT_ALLOC_PROGRESS, teaboxlib.INIT_SET_STATUS,
+	teaboxlib.INIT_RESET,
+	teaboxlib.LOGGER_STATUS, teaboxlib.LOGGER_TITLE,
+	teaboxlib.LIST_TITLE, teaboxlib.LIST_STATUS, teaboxlib.LIST_HEADER, teaboxlib.LIST_ADD, teaboxlib.LIST_CLEAR,
+	teaboxlib.LIST_VALUE,
+	teaboxlib.TERMINAL_TITLE, teaboxlib.TERMINAL_STATUS,
+	teaboxlib.COMMON_PROGRESS_EVENT, teaboxlib.COMMON_PROGRESS_ALLOCATE, teaboxlib.COMMON_PROGRESS_NEXT,
+	teaboxlib.COMMON_PROGRESS_SET, teaboxlib.COMMON_LOOKUP_PREFIX, teaboxlib.COMMON_LOOKUP_GLOB,
+	teaboxlib.COMMON_LOOKUP_REGEX, teaboxlib.COMMON_LIST_ADD_ITEM, teaboxlib.COMMON_LIST_COMPLETE_ITEM,
+	teaboxlib.COMMON_LIST_RESET, teaboxlib.COMMON_INFO_ADD, teaboxlib.COMMON_INFO_SET, teaboxlib.COMMON_TITLE,
+	teaboxlib.COMMON_RESET,
+	teaboxlib.FORM_SET_BY_LABEL, teaboxlib.FORM_SET_BY_ORD, teaboxlib.FORM_ADD_BY_LABEL, teaboxlib.FORM_ADD_BY_ORD,
+	teaboxlib.FORM_CLR_BY_LABEL, teaboxlib.FORM_CLR_BY_ORD, teaboxlib.FORM_ADD_TABLE_BY_ORD,
+	teaboxlib.FORM_SET_TABLE_BY_ORD, teaboxlib.FORM_CLR_TABLE_BY_ORD, teaboxlib.FORM_GET_BY_LABEL,
+	teaboxlib.FORM_GET_BY_ORD, teaboxlib.FORM_DUMP, teaboxlib.FORM_HIDE_BY_LABEL, teaboxlib.FORM_HIDE_BY_ORD,
+	teaboxlib.FORM_SHOW_BY_LABEL, teaboxlib.FORM_SHOW_BY_ORD, teaboxlib.FORM_DISABLE_BY_LABEL,
+	teaboxlib.FORM_DISABLE_BY_ORD, teaboxlib.FORM_ENABLE_BY_LABEL, teaboxlib.FORM_ENABLE_BY_ORD,
+	teaboxlib.SESSION_SET, teaboxlib.SESSION_GET, teaboxlib.SESSION_KEYS, teaboxlib.SESSION_DELETE,
+	teaboxlib.SESSION_FLUSH,
+	teaboxlib.DIALOG_CONFIRM, teaboxlib.DIALOG_INPUT, teaboxlib.DIALOG_PASSWORD, teaboxlib.DIALOG_CHOOSE,
+}
+
+// NewTeaboxFakeServer constructor. The server is started on a socket in a temporary directory.
+func NewTeaboxFakeServer() (*TeaboxFakeServer, error) {
+	dir, err := os.MkdirTemp("", "teabox-fake-")
+	if err != nil {
+		return nil, err
+	}
+
+	tfs := &TeaboxFakeServer{
+		dir:     dir,
+		values:  map[string]interface{}{},
+		session: map[string]string{},
+		errors:  map[string]error{},
+		answers: map[string]string{},
+		calls:   []*teaboxlib.TeaboxAPICall{},
+	}
+	tfs.server = teaboxlib.NewTeaboxSocketServer().AddGlobalAction(tfs.action)
+	if err := tfs.server.Start(filepath.Join(dir, "teabox.sock")); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	return tfs, nil
+}
+
+// SetFields of the form by their labels, in their order. Once set, the calls to the other fields fail, as in Teabox.
+func (tfs *TeaboxFakeServer) SetFields(labels ...string) *TeaboxFakeServer {
+	tfs.mtx.Lock()
+	defer tfs.mtx.Unlock()
+
+	tfs.fields = labels
+	return tfs
+}
+
+// SetError makes all calls of the class fail with the error
+func (tfs *TeaboxFakeServer) SetError(class string, err error) *TeaboxFakeServer {
+	tfs.mtx.Lock()
+	defer tfs.mtx.Unlock()
+
+	tfs.errors[class] = err
+	return tfs
+}
+
+// SetAnswer of the user to all dialogs of the class. Without an answer, a confirmation is answered
+// with "no", a choice with its first option, and an input with its value. A cancelled dialog is set
+// with SetError and teaboxlib.DIALOG_CANCELLED message.
+func (tfs *TeaboxFakeServer) SetAnswer(class, answer string) *TeaboxFakeServer {
+	tfs.mtx.Lock()
+	defer tfs.mtx.Unlock()
+
+	tfs.answers[class] = answer
+	return tfs
+}
+
+// GetSocketPath returns the path of the socket of the server
+func (tfs *TeaboxFakeServer) GetSocketPath() string {
+	return tfs.server.GetSocketPath()
+}
+
+// GetEnv returns the environment, which Teabox passes to a module
+func (tfs *TeaboxFakeServer) GetEnv() []string {
+	return teaboxlib.GetRunEnv(tfs.GetSocketPath(), "fake", "1", "")
+}
+
+// GetClient returns a new client of the server
+func (tfs *TeaboxFakeServer) GetClient() *TeaboxClient {
+	return NewTeaboxClientAt(tfs.GetSocketPath(), "")
+}
+
+// GetCalls returns all calls, which were done so far, in their order
+func (tfs *TeaboxFakeServer) GetCalls() []*teaboxlib.TeaboxAPICall {
+	tfs.mtx.Lock()
+	defer tfs.mtx.Unlock()
+
+	return append([]*teaboxlib.TeaboxAPICall{}, tfs.calls...)
+}
+
+// GetCallsOf returns the calls of the class, which were done so far, in their order
+func (tfs *TeaboxFakeServer) GetCallsOf(class string) []*teaboxlib.TeaboxAPICall {
+	calls := []*teaboxlib.TeaboxAPICall{}
+	for _, call := range tfs.GetCalls() {
+		if call.GetClass() == class {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// GetField returns the value of a field by its label, as it was set by the calls
+func (tfs *TeaboxFakeServer) GetField(label string) interface{} {
+	tfs.mtx.Lock()
+	defer tfs.mtx.Unlock()
+
+	return tfs.values[label]
+}
+
+// GetSession returns the value of the session
+func (tfs *TeaboxFakeServer) GetSession(key string) string {
+	tfs.mtx.Lock()
+	defer tfs.mtx.Unlock()
+
+	return tfs.session[key]
+}
+
+// Stop the server and remove its socket
+func (tfs *TeaboxFakeServer) Stop() error {
+	defer os.RemoveAll(tfs.dir)
+	return tfs.server.Stop()
+}
+
+// Check if the field of the call is on the form
+func (tfs *TeaboxFakeServer) hasField(call *teaboxlib.TeaboxAPICall) bool {
+	if tfs.fields == nil {
+		return true
+	}
+	if strings.HasSuffix(call.GetClass(), ".by-ord") {
+		return call.GetKeyAsInt() >= 0 && call.GetKeyAsInt() < len(tfs.fields)
+	}
+	for _, label := range tfs.fields {
+		if label == call.GetKey() {
+			return true
+		}
+	}
+	return false
+}
+
+// Get the label of the field of the call, which is its key, or the label by its order
+func (tfs *TeaboxFakeServer) getFieldLabel(call *teaboxlib.TeaboxAPICall) string {
+	if tfs.fields != nil && strings.HasSuffix(call.GetClass(), ".by-ord") {
+		return tfs.fields[call.GetKeyAsInt()]
+	}
+	return call.GetKey()
+}
+
+// Get the answer of the user to the dialog of the call
+func (tfs *TeaboxFakeServer) getAnswer(call *teaboxlib.TeaboxAPICall) string {
+	if answer, ok := tfs.answers[call.GetClass()]; ok {
+		return answer
+	}
+
+	switch call.GetClass() {
+	case teaboxlib.DIALOG_CONFIRM:
+		return teaboxlib.DIALOG_NO
+	case teaboxlib.DIALOG_CHOOSE:
+		opt, _, _ := strings.Cut(call.GetString(), "|")
+		return strings.TrimSpace(opt)
+	}
+	return call.GetString()
+}
+
+// Accept the calls, which Teabox knows
+func (tfs *TeaboxFakeServer) action(call *teaboxlib.TeaboxAPICall) string {
+	tfs.mtx.Lock()
+	defer tfs.mtx.Unlock()
+
+	known := false
+	for _, class := range fakeClasses {
+		known = known || class == call.GetClass()
+	}
+	if !known {
+		return ""
+	}
+
+	if err := tfs.errors[call.GetClass()]; err != nil {
+		call.SetError(err)
+		return ""
+	}
+	if strings.HasPrefix(call.GetClass(), "field.") && !tfs.hasField(call) {
+		call.SetNotFound(fmt.Errorf("no such field \"%s\"", call.GetKey()))
+		return ""
+	}
+
+	tfs.calls = append(tfs.calls, call)
+	call.SetHandled()
+
+	var ret string
+	switch call.GetClass() {
+	case teaboxlib.FORM_SET_BY_LABEL, teaboxlib.FORM_SET_BY_ORD:
+		if call.GetType() == "bool" {
+			tfs.values[tfs.getFieldLabel(call)] = call.GetBool()
+		} else {
+			tfs.values[tfs.getFieldLabel(call)] = call.GetString()
+		}
+	case teaboxlib.FORM_CLR_BY_LABEL, teaboxlib.FORM_CLR_BY_ORD:
+		delete(tfs.values, tfs.getFieldLabel(call))
+	case teaboxlib.FORM_GET_BY_LABEL, teaboxlib.FORM_GET_BY_ORD:
+		if value, ok := tfs.values[tfs.getFieldLabel(call)]; ok {
+			ret = fmt.Sprintf("%v", value)
+		}
+	case teaboxlib.FORM_DUMP:
+		data, _ := json.Marshal([]map[string]interface{}{{"title": "fake", "fields": tfs.values}})
+		ret = string(data)
+	case teaboxlib.SESSION_SET:
+		tfs.session[call.GetKey()] = call.GetValue().(string)
+	case teaboxlib.SESSION_GET:
+		ret = tfs.session[call.GetKey()]
+	case teaboxlib.SESSION_KEYS:
+		keys := []string{}
+		for key := range tfs.session {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		ret = strings.Join(keys, ",")
+	case teaboxlib.SESSION_DELETE:
+		delete(tfs.session, call.GetString())
+	case teaboxlib.SESSION_FLUSH:
+		tfs.session = map[string]string{}
+	case teaboxlib.DIALOG_CONFIRM, teaboxlib.DIALOG_INPUT, teaboxlib.DIALOG_PASSWORD, teaboxlib.DIALOG_CHOOSE:
+		ret = tfs.getAnswer(call)
+	}
+
+	return ret
+}
@@ -1,10 +1,13 @@
 package teaboxui
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path"
 	"strings"
+	"sync"
 
 	wzlib_logger "github.com/infra-whizz/wzlib/logger"
 	"github.com/isbm/crtview"
@@ -16,11 +19,14 @@ import (
 )
 
 // TeaFormsPanel is a layer of windows, and it contains many TeaForm instances to switch between them.
+// Each run of the module has its own landing window on it, so the module can run more than once at a time.
 type TeaFormsPanel struct {
 	parent       *TeaboxArgsForm
-	landingPage  teawidgets.TeaboxLandingWindow
 	moduleConfig *teaboxlib.TeaConfModule
 	objref       map[string]interface{}
+	forms        []*teawidgets.TeaboxArgsMainWindow // Forms in the order of the module commands
+	commands     []*teaboxlib.TeaConfModCommand     // Commands of the forms, by the same index
+	run          *TeaboxRun                         // Next run, which the setup command may already pre-populate
 	*crtview.Panels
 }
 
@@ -28,22 +34,12 @@ func NewTeaFormsPanel(conf *teaboxlib.TeaConfModule, parent *TeaboxArgsForm) *Te
 	tfp := &TeaFormsPanel{
 		Panels:       crtview.NewPanels(),
 		objref:       map[string]interface{}{},
+		forms:        []*teawidgets.TeaboxArgsMainWindow{},
+		commands:     []*teaboxlib.TeaConfModCommand{},
 		moduleConfig: conf,
 		parent:       parent,
 	}
 
-	// Init landing
-	switch tfp.moduleConfig.GetLandingPageType() {
-	case "logger":
-		tfp.landingPage = teawidgets.NewTeaLoggerWindowLander()
-		tfp.AddPanel(teawidgets.LANDING_WINDOW_LOGGER, tfp.landingPage.(crtview.Primitive), true, false)
-	case "progress":
-		tfp.landingPage = teawidgets.NewTeaProgressWindowLander()
-		tfp.AddPanel(teawidgets.LANDING_WINDOW_PROGRESS, tfp.landingPage.(crtview.Primitive), true, false)
-	default:
-		panic(fmt.Sprintf("Unfortauntely, type \"%s\" of landing page is not implemented yet\n", tfp.moduleConfig.GetLandingPageType()))
-	}
-
 	return tfp
 }
 
@@ -52,21 +48,111 @@ func (tfp *TeaFormsPanel) GetModuleConfig() *teaboxlib.TeaConfModule {
 	return tfp.moduleConfig
 }
 
-func (tfp *TeaFormsPanel) GetLandingPage() teawidgets.TeaboxLandingWindow {
-	return tfp.landingPage
+// GetModuleId returns an id of the module, which is the name of its directory
+func (tfp *TeaFormsPanel) GetModuleId() string {
+	return path.Base(tfp.moduleConfig.GetModulePath())
+}
+
+// TakeRun returns the next run of the module, which is the one, prepared while the forms are shown,
+// if there is any. The landing window of the run is removed by StopLandingWindow.
+func (tfp *TeaFormsPanel) TakeRun() (*TeaboxRun, error) {
+	run := tfp.run
+	tfp.run = nil
+
+	// Run is prepared only while its forms are served, otherwise its socket could be taken since
+	if run != nil && run.GetSocketPath() != teabox.GetTeaboxApp().GetCallbackServer().GetSocketPath() {
+		tfp.RemovePanel(run.GetPanelName())
+		run = nil
+	}
+	if run == nil {
+		return tfp.newRun()
+	}
+
+	return run, nil
+}
+
+// Create a run of the module with its own Unix socket and landing window
+func (tfp *TeaFormsPanel) newRun() (*TeaboxRun, error) {
+	run := &TeaboxRun{id: tfp.parent.newRunId()}
+
+	var err error
+	if run.socket, err = teaboxlib.NewRuntimeSocketPath(run.id); err != nil {
+		return nil, err
+	}
+	token, err := teaboxlib.NewRunToken()
+	if err != nil {
+		return nil, err
+	}
+	run.env = teaboxlib.GetRunEnv(run.socket, tfp.GetModuleId(), run.id, token)
+	run.auth = teaboxlib.NewTeaboxSocketAuth(teabox.GetTeaboxApp().GetGlobalConfig().GetAuthPolicy(), token).
+		SetOnRejectAction(func(reason string) {
+			teabox.AddToFile(teaboxlib.LOG_FILENAME, fmt.Sprintf("Error: %s: %s", tfp.moduleConfig.GetTitle(), reason))
+		})
+	if teabox.GetTeaboxApp().GetGlobalConfig().IsStrictAPI() {
+		run.onError = func(class string, err error) {
+			teabox.AddToFile(teaboxlib.LOG_FILENAME, fmt.Sprintf("Error: %s: API call \"%s\": %s", tfp.moduleConfig.GetTitle(), class, err.Error()))
+		}
+	}
+	run.lander, run.panel = tfp.newLandingPage(run.id)
+	run.lander.SetEnv(run.env)
+
+	return run, nil
+}
+
+// Create a landing window for a run of the module and add it to the panel
+func (tfp *TeaFormsPanel) newLandingPage(runId string) (teawidgets.TeaboxLandingWindow, string) {
+	var lander teawidgets.TeaboxLandingWindow
+	var name string
+
+	switch tfp.moduleConfig.GetLandingPageType() {
+	case "logger":
+		lander, name = teawidgets.NewTeaLoggerWindowLander(), teawidgets.LANDING_WINDOW_LOGGER
+	case "progress":
+		lander, name = teawidgets.NewTeaProgressWindowLander(), teawidgets.LANDING_WINDOW_PROGRESS
+	case "list":
+		lander, name = teawidgets.NewTeaListWindowLander(tfp.GetModuleId()), teawidgets.LANDING_WINDOW_LIST
+	case "terminal":
+		lander, name = teawidgets.NewTeaTerminalWindowLander(), teawidgets.LANDING_WINDOW_TERMINAL
+	default:
+		panic(fmt.Sprintf("Unfortauntely, type \"%s\" of landing page is not implemented yet\n", tfp.moduleConfig.GetLandingPageType()))
+	}
+
+	name = fmt.Sprintf("%s-%s", name, runId)
+	tfp.AddPanel(name, lander.AsWidgetPrimitive(), true, false)
+
+	return lander, name
 }
 
-// GetFormsSocketListerActions returns all actions from all forms
+// GetFormsSocketListerActions returns all actions from all forms, in their order, so the first form,
+// which has a field, replies about it. The last action dumps all forms at once.
 func (tfp *TeaFormsPanel) GetFormsSocketListenerActions() []func(*teaboxlib.TeaboxAPICall) string {
 	actions := []func(*teaboxlib.TeaboxAPICall) string{}
-	for _, ref := range tfp.objref {
-		form, ok := ref.(*teawidgets.TeaboxArgsMainWindow)
-		if !ok {
-			continue
-		}
+	for _, form := range tfp.forms {
 		actions = append(actions, form.GetSocketAcceptAction())
 	}
-	return actions
+	return append(actions, tfp.getFormsDumpAction())
+}
+
+// Action, which returns the current values of all forms as JSON
+func (tfp *TeaFormsPanel) getFormsDumpAction() func(*teaboxlib.TeaboxAPICall) string {
+	return func(c *teaboxlib.TeaboxAPICall) string {
+		if c.GetClass() != teaboxlib.FORM_DUMP {
+			return ""
+		}
+
+		forms := []map[string]interface{}{}
+		for _, form := range tfp.forms {
+			forms = append(forms, map[string]interface{}{"title": form.GetSubtitle(), "fields": form.GetValues()})
+		}
+		data, err := json.Marshal(forms)
+		if err != nil {
+			c.SetError(err)
+			return ""
+		}
+
+		c.SetHandled()
+		return string(data)
+	}
 }
 
 func (tfp *TeaFormsPanel) AddPanel(name string, item crtview.Primitive, resize bool, visible bool) {
@@ -74,41 +160,161 @@ func (tfp *TeaFormsPanel) AddPanel(name string, item crtview.Primitive, resize b
 	tfp.Panels.AddPanel(name, item, resize, visible)
 }
 
-// StartListener of Unix socket, and add handlers for it.
-func (tfp *TeaFormsPanel) StartListener() error {
-	tfp.landingPage.Reset()
-	teabox.GetTeaboxApp().GetCallbackServer().
-		AddLocalAction(tfp.landingPage.GetWindowAction()).
-		AddLocalAction(func(c *teaboxlib.TeaboxAPICall) string {
-			modId := path.Base(tfp.moduleConfig.GetModulePath())
-			switch c.GetClass() {
-			case "session.set":
-				teabox.GetTeaboxApp().GetSession().Set(modId, c.GetKey(), c.GetValue())
-			case "session.get":
-				r := teabox.GetTeaboxApp().GetSession().Get(modId, c.GetKey())
-				teabox.GetTeaboxApp().GetSession()
-				if r != nil {
-					return fmt.Sprintf("%v", r)
+func (tfp *TeaFormsPanel) RemovePanel(name string) {
+	delete(tfp.objref, name)
+	tfp.Panels.RemovePanel(name)
+}
+
+// GetSessionAction returns an action, which keeps the session data of the module
+func (tfp *TeaFormsPanel) GetSessionAction() func(*teaboxlib.TeaboxAPICall) string {
+	return func(c *teaboxlib.TeaboxAPICall) string {
+		modId := tfp.GetModuleId()
+		var ret string
+		switch c.GetClass() {
+		case teaboxlib.SESSION_SET:
+			teabox.GetTeaboxApp().GetSession().Set(modId, c.GetKey(), c.GetValue())
+		case teaboxlib.SESSION_GET:
+			if r := teabox.GetTeaboxApp().GetSession().Get(modId, c.GetKey()); r != nil {
+				ret = fmt.Sprintf("%v", r)
+			}
+		case teaboxlib.SESSION_KEYS:
+			ret = strings.Join(teabox.GetTeaboxApp().GetSession().Keys(modId), ",")
+		case teaboxlib.SESSION_DELETE:
+			teabox.GetTeaboxApp().GetSession().Delete(modId, c.GetString())
+		case teaboxlib.SESSION_FLUSH:
+			teabox.GetTeaboxApp().GetSession().Flush(modId)
+		default:
+			return ""
+		}
+
+		c.SetHandled()
+		return ret
+	}
+}
+
+// GetDialogAction returns an action, which asks the user with a dialog and replies the answer,
+// once it is given. If the user cancelled the dialog, the call fails. The job is nil for the forms,
+// otherwise its dialog waits until the job is in the foreground, and is gone with the job.
+func (tfp *TeaFormsPanel) GetDialogAction(job *TeaboxJob) func(*teaboxlib.TeaboxAPICall) string {
+	return func(c *teaboxlib.TeaboxAPICall) string {
+		switch c.GetClass() {
+		case teaboxlib.DIALOG_CONFIRM, teaboxlib.DIALOG_INPUT, teaboxlib.DIALOG_PASSWORD, teaboxlib.DIALOG_CHOOSE:
+		default:
+			return ""
+		}
+
+		workspace := tfp.parent.workspace
+		title := tfp.moduleConfig.GetTitle()
+
+		// Dialog is cancelled, if the client is gone, or the job is over
+		var jobDone <-chan struct{}
+		if job != nil {
+			if !job.WaitForeground() {
+				c.SetError(errors.New(teaboxlib.DIALOG_CANCELLED))
+				return ""
+			}
+			jobDone = job.Done()
+		}
+
+		cancel := make(chan struct{})
+		answered := make(chan struct{})
+		defer close(answered)
+		go func() {
+			select {
+			case <-c.GetClosed():
+			case <-jobDone:
+			case <-answered:
+				return
+			}
+			close(cancel)
+		}()
+
+		var ret string
+		var ok bool
+		switch c.GetClass() {
+		case teaboxlib.DIALOG_CONFIRM:
+			var yes bool
+			if yes, ok = workspace.Confirm(title, c.GetString(), cancel); yes {
+				ret = teaboxlib.DIALOG_YES
+			} else {
+				ret = teaboxlib.DIALOG_NO
+			}
+		case teaboxlib.DIALOG_INPUT, teaboxlib.DIALOG_PASSWORD:
+			ret, ok = workspace.Input(title, c.GetKey(), c.GetString(), c.GetClass() == teaboxlib.DIALOG_PASSWORD, cancel)
+		case teaboxlib.DIALOG_CHOOSE:
+			options := []string{}
+			for _, opt := range strings.Split(c.GetString(), "|") {
+				if opt = strings.TrimSpace(opt); opt != "" {
+					options = append(options, opt)
 				}
-			case "session.keys":
-				return strings.Join(teabox.GetTeaboxApp().GetSession().Keys(modId), ",")
-			case "session.delete":
-				teabox.GetTeaboxApp().GetSession().Delete(modId, c.GetString())
-			case "session.flush":
-				teabox.GetTeaboxApp().GetSession().Flush(modId)
 			}
+			if len(options) == 0 {
+				c.SetError(fmt.Errorf("no options to choose from"))
+				return ""
+			}
+			ret, ok = workspace.Choose(title, c.GetKey(), options, cancel)
+		}
 
+		if !ok {
+			c.SetError(errors.New(teaboxlib.DIALOG_CANCELLED))
 			return ""
-		})
+		}
+
+		c.SetHandled()
+		return ret
+	}
+}
+
+// StartListener of Unix socket, and add handlers for it. This listener serves the forms of the module,
+// while they are shown, and the runs have their own ones.
+func (tfp *TeaFormsPanel) StartListener() error {
+	server := teabox.GetTeaboxApp().GetCallbackServer()
+	if server.IsRunning() {
+		if err := server.Stop(); err != nil {
+			return err
+		}
+	}
+	// Setup command and signals already talk to the landing window of the next run, on its socket
+	if tfp.run != nil {
+		tfp.RemovePanel(tfp.run.GetPanelName())
+	}
+	run, err := tfp.newRun()
+	if err != nil {
+		return err
+	}
+	tfp.run = run
+	for _, form := range tfp.forms {
+		form.SetEnv(run.GetEnv())
+	}
+	server.SetAuth(run.GetAuth()).SetOnErrorAction(run.GetErrorAction()).AddLocalAction(run.GetLandingPage().GetWindowAction(), tfp.GetSessionAction(), tfp.GetDialogAction(nil))
+
+	// Setup command and signals also read and update the forms
+	server.AddLocalAction(tfp.GetFormsSocketListenerActions()...)
 
 	// Run the Unix server instance
-	if err := teabox.GetTeaboxApp().GetCallbackServer().Start(tfp.moduleConfig.GetCallbackPath()); err != nil {
+	if err := server.Start(run.GetSocketPath()); err != nil {
 		panic(fmt.Sprintf("Error starting listener: %s", err.Error()))
 	}
 
 	return nil
 }
 
+// GetEnv returns the environment for the commands of the next run, e.g. its setup command
+func (tfp *TeaFormsPanel) GetEnv() []string {
+	if tfp.run == nil {
+		return []string{}
+	}
+	return tfp.run.GetEnv()
+}
+
+// StopListener of the forms, once the module is started
+func (tfp *TeaFormsPanel) StopListener() error {
+	if teabox.GetTeaboxApp().GetCallbackServer().IsRunning() {
+		return teabox.GetTeaboxApp().GetCallbackServer().Stop()
+	}
+	return nil
+}
+
 // SkipLoad, if there is at least one form mute with skipping load.
 func (tfp *TeaFormsPanel) SkipLoad() bool {
 	for _, ref := range tfp.objref {
@@ -123,39 +329,50 @@ func (tfp *TeaFormsPanel) SkipLoad() bool {
 	return false
 }
 
-// ShowLandingWindow and start Unix socket server listener with the current callback pack
-func (tfp *TeaFormsPanel) ShowLandingWindow(id string) error {
-	// Setup local action for the future instance
-	teabox.GetTeaboxApp().GetCallbackServer().AddLocalAction(tfp.landingPage.GetWindowAction())
-	switch id {
-	case "logger":
-		tfp.SetCurrentPanel(teawidgets.LANDING_WINDOW_LOGGER)
-	case "progress":
-		tfp.SetCurrentPanel(teawidgets.LANDING_WINDOW_PROGRESS)
-	default:
-		tfp.SetCurrentPanel(teawidgets.LANDING_WINDOW_LOGGER)
-	}
-
-	return nil
+// ShowLandingWindow of the run
+func (tfp *TeaFormsPanel) ShowLandingWindow(job *TeaboxJob) {
+	tfp.SetCurrentPanel(job.GetPanelName())
+	teabox.GetTeaboxApp().SetFocus(job.GetLandingPage().AsWidgetPrimitive()) // Lander receives Esc to cancel the run
 }
 
-// StopLandingWindow switches back to the caller form and stops the Unix socket listener
-func (tfp *TeaFormsPanel) StopLandingWindow(fid string) error {
+// StopLandingWindow of the finished run, switching back to the first form
+func (tfp *TeaFormsPanel) StopLandingWindow(job *TeaboxJob) {
 	tfp.parent.ShowIntroScreen()
-	tfp.SetCurrentPanel(fid) // Close everything, back to the selector
-	tfp.landingPage.Reset()  // Cleanup/reset the lander
-	return tfp.landingPage.StopListener()
+	tfp.ShowForm(0) // Close everything, back to the selector
+	tfp.RemovePanel(job.GetPanelName())
+}
+
+// AddForm of the next command of the module. Command is nil, if the form only shows a message.
+func (tfp *TeaFormsPanel) AddForm(title, subtitle string, cmd *teaboxlib.TeaConfModCommand) *teawidgets.TeaboxArgsMainWindow {
+	f := teawidgets.NewTeaboxArgsMainWindow(title, subtitle, len(tfp.forms))
+	tfp.AddPanel(f.GetId(), f, true, len(tfp.forms) == 0)
+	tfp.forms = append(tfp.forms, f)
+	tfp.commands = append(tfp.commands, cmd)
+
+	return f
 }
 
-func (tfp *TeaFormsPanel) GetFormItem(title, subtitle string) interface{} {
-	return tfp.objref[fmt.Sprintf("%s - %s", title, subtitle)]
+// GetCommand returns the command of a form by its index, or nil, if there is none
+func (tfp *TeaFormsPanel) GetCommand(idx int) *teaboxlib.TeaConfModCommand {
+	if idx < 0 || idx >= len(tfp.commands) {
+		return nil
+	}
+	return tfp.commands[idx]
 }
 
-func (tfp *TeaFormsPanel) AddForm(title, subtitle string) *teawidgets.TeaboxArgsMainWindow {
-	f := teawidgets.NewTeaboxArgsMainWindow(title, subtitle)
-	tfp.AddPanel(f.GetId(), f, true, tfp.GetPanelCount() == 1)
+// GetForms returns all forms in the order of the module commands
+func (tfp *TeaFormsPanel) GetForms() []*teawidgets.TeaboxArgsMainWindow {
+	return tfp.forms
+}
 
-	return tfp.GetFormItem(title, subtitle).(*teawidgets.TeaboxArgsMainWindow)
+// ShowForm switches to a form (a wizard page) by its index
+func (tfp *TeaFormsPanel) ShowForm(idx int) {
+	if idx < 0 || idx >= len(tfp.forms) {
+		return
+	}
+
+	tfp.SetCurrentPanel(tfp.forms[idx].GetId())
+	teabox.GetTeaboxApp().SetFocus(tfp.forms[idx])
 }
 
 // TeaboxArgsForm contains a layers with TeaForms on it, also their output, intro screen, callback screens etc.
@@ -169,31 +386,52 @@ type TeaboxArgsForm struct {
 	*/
 	allModulesForms *TeaboxArgsFormPanels
 
-	/*
-		This is not super-nice, but still, per each form (via GetId() method) it holds the following:
-
-		  1. A map of all named arguments with their values, e.g. "--path=/dev/null" etc.
-		  2. An array of named arguments without their values in order to put an order to the map above.
-		  3. An array of flags, like "-d -v -x -y -z" etc. Currently flags are always passed first.
+	// History of the past jobs
+	history *teawidgets.TeaboxArgsHistoryWindow
 
-		Note, these are all arguments to the *module*, so if Teabox is not flexible enough to call a random
-		userland command, one can always wrap it with a shell script. :-)
-
-		NOTE: If you still think this is dumb, feel free to make it better and send your PR!
-	*/
-	modCmdIndex map[string]*teaboxlib.TeaConfModCommand
+	// Jobs, which are running or finished, but their result is not yet seen. Only one of them
+	// is in the foreground, the others are running in the background.
+	jobs        []*TeaboxJob
+	foreground  *TeaboxJob
+	jobsStarted int
+	jobsWindow  *teawidgets.TeaboxArgsJobsWindow
+	jobsMtx     sync.Mutex
 
 	wzlib_logger.WzLogger
 }
 
 func NewTeaboxArgsForm(workspace *TeaboxWorkspacePanels) *TeaboxArgsForm {
 	taf := new(TeaboxArgsForm)
-	taf.modCmdIndex = map[string]*teaboxlib.TeaConfModCommand{}
 	taf.workspace = workspace
 
 	return taf.init()
 }
 
+// Ask a yes/no question of an optional command. This blocks until the user answers,
+// so it must be called outside of the UI event loop.
+func (taf *TeaboxArgsForm) askOption(title, question string) bool {
+	answer := make(chan bool, 1)
+	popup := taf.workspace.questionPopup
+	popup.SetTitle(title)
+	popup.SetTextAutofill(false)
+	popup.SetMessage(question)
+	popup.SetOnConfirmAction(func() {
+		taf.workspace.HidePanel("_question-popup")
+		answer <- true
+	})
+	popup.SetOnCancelAction(func() {
+		taf.workspace.HidePanel("_question-popup")
+		answer <- false
+	})
+
+	taf.workspace.ShowPanel("_question-popup")
+	popup.SetFocus(1)                     // Message is the first item, so "Yes" button is the second
+	teabox.GetTeaboxApp().SetFocus(popup) // Focus the form, so Tab switches between the buttons
+	teabox.GetTeaboxApp().Draw()
+
+	return <-answer
+}
+
 func (taf *TeaboxArgsForm) GetWidget() crtview.Primitive {
 	return taf.allModulesForms
 }
@@ -227,7 +465,6 @@ func (taf *TeaboxArgsForm) ShowModuleForm(id string) {
 
 			// Set receiver hooks
 			teabox.GetTeaboxApp().GetCallbackServer().AddLocalAction(loader.GetSocketAcceptAction())
-			teabox.GetTeaboxApp().GetCallbackServer().AddLocalAction(formsPanel.GetFormsSocketListenerActions()...)
 
 			// Loader command could have relative path or absolute.
 			// Current directory ("./") is not supported
@@ -247,11 +484,13 @@ func (taf *TeaboxArgsForm) ShowModuleForm(id string) {
 			}
 
 			// Skip loader if conditions are not met
-			if formsPanel.SkipLoad() {
+			if formsPanel.SkipLoad() || len(formsPanel.GetModuleConfig().GetCommands()) == 0 {
 				loader.SkipLoad()
 			} else {
-				// Call the loader to pre-populate everything
-				if err := loader.Load(cmd, formsPanel.GetModuleConfig().GetSetupCommandArgs()...); err != nil {
+				// Call the loader to pre-populate everything. It runs with the settings of the first command,
+				// which form is about to be pre-populated.
+				modcmd := formsPanel.GetModuleConfig().GetCommands()[0]
+				if err := loader.Load(modcmd, formsPanel.GetEnv(), cmd, formsPanel.GetModuleConfig().GetSetupCommandArgs()...); err != nil {
 					teabox.GetTeaboxApp().GetScreen().Clear()
 					taf.GetLogger().Panic(err)
 				}
@@ -275,6 +514,35 @@ func (taf *TeaboxArgsForm) init() *TeaboxArgsForm {
 	// Add argloading window (common for all)
 	taf.allModulesForms.AddPanel(teawidgets.LOAD_WINDOW_COMMON, teawidgets.NewTeaboxArgsLoadingWindow(), true, false)
 
+	// Add history of the jobs (common for all)
+	taf.history = teawidgets.NewTeaboxArgsHistoryWindow()
+	taf.history.SetOnViewAction(func(rec *teaboxlib.TeaboxJobRecord) {
+		taf.workspace.ShowOutput(rec.GetModuleTitle(), teawidgets.DescribeJob(rec), func() {
+			teabox.GetTeaboxApp().SetFocus(taf.history)
+		})
+	}).SetOnRerunAction(taf.rerunJob).SetOnCloseAction(func() {
+		taf.ShowIntroScreen()
+		GetTeaboxMainWindow().GetMainMenu().FocusCurrentMenu()
+	})
+	taf.allModulesForms.AddPanel(teawidgets.HISTORY_WINDOW_COMMON, taf.history, true, false)
+
+	// Add jobs list (common for all)
+	taf.jobsWindow = teawidgets.NewTeaboxArgsJobsWindow()
+	taf.jobsWindow.SetOnAttachAction(func(idx int) {
+		if job := taf.getJob(idx); job != nil {
+			taf.attachJob(job)
+		}
+	}).SetOnCancelAction(func(idx int) {
+		if job := taf.getJob(idx); job != nil && job.GetState() != JOB_FINISHED {
+			taf.cancelJob(job)
+		}
+	}).SetOnCloseAction(func() {
+		taf.ShowIntroScreen()
+		GetTeaboxMainWindow().GetMainMenu().FocusCurrentMenu()
+	})
+	taf.allModulesForms.AddPanel(teawidgets.JOBS_WINDOW_COMMON, taf.jobsWindow, true, false)
+	taf.updateJobs()
+
 	// Build all individual forms
 	for _, mod := range teabox.GetTeaboxApp().GetGlobalConfig().GetModuleStructure() {
 		if err := taf.generateForms(mod); err != nil {
@@ -306,15 +574,29 @@ func (taf *TeaboxArgsForm) generateForms(c teaboxlib.TeaConfComponent) error {
 		}
 	}
 
+	mod, ok := c.(*teaboxlib.TeaConfModule)
+	if ok && len(mod.GetErrors()) > 0 {
+		taf.generateBrokenForm(mod)
+		return nil
+	}
+
 	if len(c.GetCommands()) < 1 {
 		return nil
 	}
 
-	mod := c.(*teaboxlib.TeaConfModule) // Only module can have at least command
 	formPanel := NewTeaFormsPanel(mod, taf)
 
-	for _, cmd := range mod.GetCommands() { // One form can have many tabs!
-		f := formPanel.AddForm(mod.GetTitle(), cmd.GetTitle()).SetStaticFlags(cmd)
+	// Process module conditions
+	conditions, err := teaconditions.NewTeaConditionsProcessor(mod.GetConditions())
+	if err != nil {
+		return err
+	}
+
+	// Each command is a page of a wizard, and the last page starts them all in a chain
+	commands := mod.GetCommands()
+	for idx, cmd := range commands {
+		idx := idx
+		f := formPanel.AddForm(mod.GetTitle(), cmd.GetTitle(), cmd).SetStaticFlags(cmd)
 		f.SetFocusedBorderStyle(crtview.BorderSingle)
 		f.SetBorderColor(teaboxlib.FORM_BORDER)
 		f.SetBorderColorFocused(teaboxlib.FORM_BORDER_SELECTED)
@@ -327,73 +609,356 @@ func (taf *TeaboxArgsForm) generateForms(c teaboxlib.TeaConfComponent) error {
 		f.SetFieldBackgroundColorFocused(teaboxlib.FORM_FIELD_BACKGROUND_FOCUSED)
 		f.SetFieldBackgroundColor(teaboxlib.FORM_FIELD_BACKGROUND)
 
+		if len(commands) > 1 {
+			f.SetTitle(fmt.Sprintf("%s - %s (%d/%d)", mod.GetTitle(), cmd.GetTitle(), idx+1, len(commands)))
+		}
+
 		// Update relative path to its absolute
 		if !strings.HasPrefix(cmd.GetCommandPath(), "/") {
 			cmd.SetCommandPath(path.Join(mod.GetModulePath(), cmd.GetCommandPath()))
 		}
-		taf.modCmdIndex[f.GetId()] = cmd
 
-		// Process module conditions
-		conditions, err := teaconditions.NewTeaConditionsProcessor(mod.GetConditions())
-		if err != nil {
-			return err
-		}
 		if !conditions.Satisfied() {
 			f.SetSkipLoad(func() {
 				teabox.GetTeaboxApp().SetFocus(GetTeaboxMainWindow().GetMainMenu().GetWidget())
 				taf.ShowIntroScreen()
 			}, conditions.GetInfoMessage())
-		} else {
-			// Build a module UI, conditions are met.
-			//
-			// Add arguments
-			f.AddArgWidgets(cmd)
+			break // Module won't run anyway, so the rest of the pages are not needed
+		}
 
-			// Or next/previous, if not the last form
+		// Build a module UI, conditions are met.
+		//
+		// Add arguments
+		if err := f.AddArgWidgets(cmd); err != nil {
+			return err
+		}
+
+		if idx > 0 {
+			f.AddButton("Previous", func() {
+				formPanel.ShowForm(idx - 1)
+			})
+		}
+
+		if idx < len(commands)-1 {
+			f.AddButton("Next", func() {
+				formPanel.ShowForm(idx + 1)
+			})
+		} else {
 			f.AddButton("Start", func() {
 				// Show resulting end-widget. Those are:
 				// - STDOUT "dumb" writer, shows just an output, like a terminal
 				// - Checklist done/todo progress screen that has various features, such as progress-bar, status etc (TODO)
 				//
-				// NOTE: landing window also starts the listener, to which Action() below connects via resulting command Action() calls.
-				formPanel.ShowLandingWindow(mod.GetLandingPageType())
-				go func() {
-					// Run command on the landing window
-					var panelPtr = "_info-popup"
-					var alert *crtwin.ModalDialog
-					modcmd := taf.modCmdIndex[f.GetId()]
-					if err := formPanel.GetLandingPage().Action(modcmd.GetCommandPath(), f.GetCommandArguments(f.GetId())...); err != nil {
-						alert = taf.workspace.alertPopup
-						alert.SetTitle(fmt.Sprintf("%s: Module Error", mod.GetTitle()))
-						alert.SetTextAutofill(false)
-						alert.SetMessage(fmt.Sprintf("Error while calling\n%s\n%s", modcmd.GetCommandPath(), err.Error()))
-						panelPtr = "_alert-popup"
-
-					} else {
-						alert = taf.workspace.infoPopup
-						alert.SetTitle("Success!")
-						alert.SetTextAutofill(false)
-						alert.SetMessage(fmt.Sprintf("%s finished", mod.GetTitle()))
-					}
-					alert.SetOnConfirmAction(func() {
-						formPanel.StopLandingWindow(f.GetId())
-						taf.workspace.HidePanel(panelPtr)
-					})
-					taf.workspace.ShowPanel(panelPtr)
-					teabox.GetTeaboxApp().SetFocus(alert.GetButton(0)) // Focus can be set only if Primitive is visible
-					teabox.GetTeaboxApp().Draw()
-				}()
+				// NOTE: each run has its own landing window and listener, to which the commands of the module connect.
+				taf.startJob(formPanel, nil, false)
 			})
-
-			f.AddButton("Cancel", func() {
-				teabox.GetTeaboxApp().SetFocus(GetTeaboxMainWindow().GetMainMenu().GetWidget())
-				taf.ShowIntroScreen()
+			f.AddButton("Run in background", func() {
+				taf.startJob(formPanel, nil, true)
 			})
-
 		}
-		break // currently we take only a first command
+
+		f.AddButton("Cancel", func() {
+			formPanel.ShowForm(0)
+			teabox.GetTeaboxApp().SetFocus(GetTeaboxMainWindow().GetMainMenu().GetWidget())
+			taf.ShowIntroScreen()
+		})
 	}
 
 	taf.allModulesForms.AddPanel(mod.GetTitle(), formPanel, true, false)
 	return nil
 }
+
+// Run all commands of the module chain on its landing window, one after another, and show the result.
+// The job is recorded to the history. If a past job is given, its commands run again with the same
+// arguments, skipping the same optional ones, instead of the ones from the forms.
+// Commands, which need the user, such as questions or interactive commands, wait until the job is attached.
+func (taf *TeaboxArgsForm) runModule(job *TeaboxJob, rerun *teaboxlib.TeaboxJobRecord) {
+	var panelPtr string
+	var alert *crtwin.ModalDialog
+	var err error
+	var modcmd *teaboxlib.TeaConfModCommand
+	var result *teaboxlib.TeaConfCmdResult
+
+	formPanel := job.GetFormPanel()
+	mod := formPanel.GetModuleConfig()
+	record := job.GetRecord()
+	exitCode := 0
+	secrets := []string{}
+
+	// Declared results of all the steps are shown together, not only the last one
+	results := []*teaboxlib.TeaConfCmdResult{}
+	resultSteps := []string{}
+
+	lander := job.GetLandingPage()
+	chain := len(formPanel.GetForms()) > 1
+	setStep := func(idx int, state int) {
+		if chain { // Single command is not a chain, no steps to report
+			lander.SetStepState(idx, modcmd.GetTitle(), state)
+		}
+	}
+
+	// All commands are recorded beforehand, so the ones which are never reached are still in the history
+	forms := formPanel.GetForms()
+	args := make([][]string, len(forms))
+	for idx, form := range forms {
+		modcmd = formPanel.GetCommand(idx)
+		if rerun != nil {
+			args[idx] = rerun.GetCommands()[idx].GetArguments()
+			record.AddCommand(modcmd.GetTitle(), modcmd.GetCommandPath(), args[idx], false)
+		} else {
+			args[idx] = form.GetCommandArguments(form.GetId())
+			jobargs, masked := form.GetMaskedCommandArguments(form.GetId())
+			secrets = append(secrets, form.GetSecrets()...)
+			record.AddCommand(modcmd.GetTitle(), modcmd.GetCommandPath(), jobargs, masked)
+		}
+	}
+
+	for idx := range forms {
+		modcmd = formPanel.GetCommand(idx)
+		jobcmd := record.GetCommands()[idx]
+		cmdargs := args[idx]
+		if job.IsCancelled() {
+			err = teawidgets.ErrLanderCancelled
+			jobcmd.SetResult(teaboxlib.JOB_STATE_CANCELLED, -1)
+			break
+		}
+
+		// Commands which ran before are not asked again, the skipped ones are skipped again
+		skip := false
+		if rerun != nil && rerun.GetCommands()[idx].GetState() != teaboxlib.JOB_STATE_NOT_RUN {
+			skip = rerun.GetCommands()[idx].GetState() == teaboxlib.JOB_STATE_SKIPPED
+		} else if modcmd.GetOptionLabel() != "" { // Optional command requires a yes/no before proceed
+			if !job.WaitForeground() {
+				err = teawidgets.ErrLanderCancelled
+				jobcmd.SetResult(teaboxlib.JOB_STATE_CANCELLED, -1)
+				break
+			}
+			skip = !taf.askOption(mod.GetTitle(), modcmd.GetOptionLabel())
+		}
+
+		if skip {
+			setStep(idx, teawidgets.STEP_SKIPPED)
+			jobcmd.SetResult(teaboxlib.JOB_STATE_SKIPPED, -1)
+			continue
+		}
+
+		setStep(idx, teawidgets.STEP_RUNNING)
+		if modcmd.IsInteractive() {
+			if !job.WaitForeground() {
+				err = teawidgets.ErrLanderCancelled
+			} else {
+				err = teawidgets.RunInteractive(modcmd, job.GetEnv(), cmdargs...)
+			}
+		} else {
+			err = lander.Action(modcmd, cmdargs...)
+		}
+
+		// Exit code, declared in the results, is presented as the module wants it
+		var exitErr *teawidgets.LanderExitError
+		if err == nil {
+			exitCode = 0
+			result = modcmd.GetResult(0)
+		} else if errors.As(err, &exitErr) {
+			exitCode = exitErr.GetExitCode()
+			result = modcmd.GetResult(exitErr.GetExitCode())
+		} else {
+			exitCode = -1
+			result = nil
+		}
+
+		if errors.Is(err, teawidgets.ErrLanderCancelled) {
+			setStep(idx, teawidgets.STEP_CANCELLED)
+			jobcmd.SetResult(teaboxlib.JOB_STATE_CANCELLED, exitCode)
+			break
+		}
+
+		if result != nil {
+			results = append(results, result)
+			resultSteps = append(resultSteps, modcmd.GetTitle())
+		}
+		if err != nil && (result == nil || result.GetSeverity() == teaboxlib.RESULT_ALERT) {
+			setStep(idx, teawidgets.STEP_FAILED)
+			jobcmd.SetResult(teaboxlib.JOB_STATE_FAILED, exitCode)
+			break
+		}
+		err = nil // Declared info or warning result is not a failure
+		setStep(idx, teawidgets.STEP_DONE)
+		jobcmd.SetResult(teaboxlib.JOB_STATE_DONE, exitCode)
+	}
+
+	transcript := ""
+	if holder, ok := lander.(teawidgets.TeaboxTranscriptHolder); ok {
+		transcript = holder.GetTranscript()
+	}
+	// Module may print its secrets, but they are not kept anyway
+	jobTranscript := transcript
+	for _, secret := range secrets {
+		jobTranscript = strings.ReplaceAll(jobTranscript, secret, teaboxlib.JOB_SECRET_MASK)
+	}
+	// History which cannot be written is told with the result, as the log file might be not writable either
+	historyNote := ""
+	if herr := teabox.GetTeaboxApp().GetHistory().Add(record.Finish(exitCode, jobTranscript)); herr != nil {
+		teabox.AddToFile(teaboxlib.LOG_FILENAME, fmt.Sprintf("Error: unable to save the job history: %s", herr.Error()))
+		historyNote = fmt.Sprintf("\n\nThe run is not in the history: %s", herr.Error())
+	}
+
+	// Result is shown, once the user is there to see it
+	job.Finish(func() {
+		// Failure, which is not declared in the results, is shown after them
+		failure := ""
+		if err != nil && !errors.Is(err, teawidgets.ErrLanderCancelled) && result == nil {
+			failure = fmt.Sprintf("Error while calling\n%s\n%s", modcmd.GetCommandPath(), err.Error())
+		}
+
+		severity := teaboxlib.RESULT_INFO
+		messages := []string{}
+		for idx, res := range results {
+			if len(results) > 1 || failure != "" {
+				messages = append(messages, fmt.Sprintf("%s: %s", resultSteps[idx], res.GetMessage()))
+			} else {
+				messages = append(messages, res.GetMessage())
+			}
+			severity = worseSeverity(severity, res.GetSeverity())
+		}
+
+		if errors.Is(err, teawidgets.ErrLanderCancelled) {
+			alert, panelPtr = taf.workspace.GetSeverityPopup(teaboxlib.RESULT_WARNING)
+			alert.SetTitle("Cancelled")
+			alert.SetTextAutofill(false)
+			alert.SetMessage(strings.Join(append([]string{fmt.Sprintf("%s was cancelled", mod.GetTitle())}, messages...), "\n") + historyNote)
+
+		} else if failure != "" {
+			alert, panelPtr = taf.workspace.GetSeverityPopup(teaboxlib.RESULT_ALERT)
+			alert.SetTitle(fmt.Sprintf("%s: Module Error", mod.GetTitle()))
+			alert.SetTextAutofill(false)
+			alert.SetMessage(strings.Join(append(messages, failure), "\n") + historyNote)
+
+		} else if len(results) > 0 {
+			alert, panelPtr = taf.workspace.GetSeverityPopup(severity)
+			if len(results) == 1 && results[0].GetTitle() != "" {
+				alert.SetTitle(results[0].GetTitle())
+			} else {
+				alert.SetTitle(mod.GetTitle())
+			}
+			alert.SetTextAutofill(false)
+			alert.SetMessage(strings.Join(messages, "\n") + historyNote)
+
+		} else {
+			alert, panelPtr = taf.workspace.GetSeverityPopup(teaboxlib.RESULT_INFO)
+			alert.SetTitle("Success!")
+			alert.SetTextAutofill(false)
+			alert.SetMessage(fmt.Sprintf("%s finished", mod.GetTitle()) + historyNote)
+		}
+		// Whole output can be viewed, before the landing page is reset
+		if transcript != "" {
+			taf.workspace.AddViewOutputButton(alert, panelPtr, mod.GetTitle(), transcript)
+		}
+		alert.SetOnConfirmAction(func() {
+			taf.workspace.RemoveViewOutputButton(alert)
+			taf.workspace.HidePanel(panelPtr)
+			taf.closeJob(job)
+
+			if rerun != nil {
+				// Job was started from the history, so it goes back there
+				taf.ShowHistory()
+			} else if mod.GetLandingPageType() == "terminal" {
+				// Interactive session is usually repeated, so its form is shown again
+				taf.allModulesForms.SetCurrentPanel(mod.GetTitle())
+				formPanel.ShowForm(0)
+			} else {
+				GetTeaboxMainWindow().GetMainMenu().FocusCurrentMenu()
+			}
+		})
+		taf.workspace.ShowPanel(panelPtr)
+		alert.SetFocus(1)                     // Message is the first item, so the first button is the second
+		teabox.GetTeaboxApp().SetFocus(alert) // Focus can be set only if Primitive is visible
+		teabox.GetTeaboxApp().Draw()
+	})
+}
+
+// Pick the worse of the two result severities
+func worseSeverity(a, b string) string {
+	rank := map[string]int{teaboxlib.RESULT_INFO: 0, teaboxlib.RESULT_WARNING: 1, teaboxlib.RESULT_ALERT: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// ShowHistory of the past jobs
+func (taf *TeaboxArgsForm) ShowHistory() {
+	taf.history.Load(teabox.GetTeaboxApp().GetHistory().GetRecords())
+	taf.allModulesForms.SetCurrentPanel(teawidgets.HISTORY_WINDOW_COMMON)
+	teabox.GetTeaboxApp().SetFocus(taf.history)
+}
+
+// Run a past job again with the same arguments. Jobs with the secret arguments cannot, as those
+// are not stored, and neither the jobs of the modules, which have changed since.
+func (taf *TeaboxArgsForm) rerunJob(rec *teaboxlib.TeaboxJobRecord) {
+	formPanel, ok := taf.allModulesForms.GetPanelByName(rec.GetModuleTitle()).(*TeaFormsPanel)
+	if ok && !formPanel.SkipLoad() && len(formPanel.GetForms()) == len(rec.GetCommands()) {
+		for idx := range formPanel.GetForms() {
+			modcmd := formPanel.GetCommand(idx)
+			if modcmd == nil || modcmd.GetTitle() != rec.GetCommands()[idx].GetTitle() ||
+				modcmd.GetCommandPath() != rec.GetCommands()[idx].GetCommandPath() {
+				ok = false
+				break
+			}
+		}
+	} else {
+		ok = false
+	}
+
+	msg := ""
+	if !ok {
+		msg = fmt.Sprintf("%s has changed since, or cannot run at the moment.\nPlease start it from the menu.", rec.GetModuleTitle())
+	} else if rec.IsMasked() {
+		msg = fmt.Sprintf("Secret arguments of %s are not kept in the history.\nPlease start it from the menu.", rec.GetModuleTitle())
+	}
+
+	if msg != "" {
+		alert, panelPtr := taf.workspace.GetSeverityPopup(teaboxlib.RESULT_WARNING)
+		alert.SetTitle("Cannot run again")
+		alert.SetTextAutofill(false)
+		alert.SetMessage(msg)
+		alert.SetOnConfirmAction(func() {
+			taf.workspace.HidePanel(panelPtr)
+			teabox.GetTeaboxApp().SetFocus(taf.history)
+		})
+		taf.workspace.ShowPanel(panelPtr)
+		alert.SetFocus(1)
+		teabox.GetTeaboxApp().SetFocus(alert)
+		return
+	}
+
+	taf.startJob(formPanel, rec, false)
+}
+
+// generateBrokenForm for a module, which configuration has errors. Such module cannot run,
+// so its form only displays what is wrong with it.
+func (taf *TeaboxArgsForm) generateBrokenForm(mod *teaboxlib.TeaConfModule) {
+	msg := []string{"[red]This module cannot run due to configuration errors:[-]", ""}
+	for _, err := range mod.GetErrors() {
+		if err.GetKey() != "" {
+			msg = append(msg, fmt.Sprintf("%s: %s", err.GetKey(), err.GetMessage()))
+		} else {
+			msg = append(msg, err.GetMessage())
+		}
+	}
+
+	formPanel := NewTeaFormsPanel(mod, taf)
+	f := formPanel.AddForm(mod.GetTitle(), "Error", nil)
+	f.SetFocusedBorderStyle(crtview.BorderSingle)
+	f.SetBorderColor(teaboxlib.FORM_BORDER)
+	f.SetBorderColorFocused(teaboxlib.FORM_BORDER_SELECTED)
+	f.SetButtonBackgroundColor(teaboxlib.FORM_BUTTON_BACKGROUND)
+	f.SetButtonBackgroundColorFocused(teaboxlib.FORM_BUTTON_BACKGROUND_SELECTED)
+	f.SetButtonTextColor(teaboxlib.FORM_BUTTON_TEXT)
+	f.SetButtonTextColorFocused(teaboxlib.FORM_BUTTON_TEXT_SELECTED)
+	f.SetSkipLoad(func() {
+		teabox.GetTeaboxApp().SetFocus(GetTeaboxMainWindow().GetMainMenu().GetWidget())
+		taf.ShowIntroScreen()
+	}, strings.Join(msg, "\n"))
+
+	taf.allModulesForms.AddPanel(mod.GetTitle(), formPanel, true, false)
+}
@@ -0,0 +1,456 @@
+package teaboxui
+
+import (
+	"fmt"
+	"sync"
+
+	"gitlab.com/isbm/teabox"
+	"gitlab.com/isbm/teabox/teaboxlib"
+	"gitlab.com/isbm/teabox/teaboxlib/teaboxui/teawidgets"
+)
+
+// States of a job
+const (
+	JOB_RUNNING  = iota
+	JOB_WAITING  // Needs the user, so it waits to be attached
+	JOB_FINISHED // Result is not yet seen
+)
+
+// TeaboxJob is a run of a module. It has its own landing window and Unix socket,
+// so it keeps running in the background, while the user is doing something else.
+type TeaboxJob struct {
+	formPanel  *TeaFormsPanel
+	record     *teaboxlib.TeaboxJobRecord
+	run        *TeaboxRun
+	server     *teaboxlib.TeaboxSocketServer
+	state      int
+	background bool
+	cancelled  bool
+	result     func() // Shows the result, once the job is finished
+	onChange   func()
+	attached   *sync.Cond
+	done       chan struct{}
+	mtx        sync.Mutex
+}
+
+// TeaboxRun is a run of a module with its id, landing window and Unix socket. It is prepared
+// while the forms of the module are shown, so the setup command already talks to it.
+type TeaboxRun struct {
+	id      string
+	socket  string
+	env     []string
+	auth    *teaboxlib.TeaboxSocketAuth
+	onError func(class string, err error)
+	lander  teawidgets.TeaboxLandingWindow
+	panel   string
+}
+
+// GetId of the run, unique within the instance of Teabox
+func (run *TeaboxRun) GetId() string {
+	return run.id
+}
+
+// GetSocketPath returns the path of the Unix socket of the run
+func (run *TeaboxRun) GetSocketPath() string {
+	return run.socket
+}
+
+// GetEnv returns the environment, which is added to the commands of the run
+func (run *TeaboxRun) GetEnv() []string {
+	return run.env
+}
+
+// GetAuth returns the authentication of the clients of the socket of the run
+func (run *TeaboxRun) GetAuth() *teaboxlib.TeaboxSocketAuth {
+	return run.auth
+}
+
+// GetErrorAction returns an action, which is called with every API call of the run that could not be done.
+// It is nil, unless the API is strict.
+func (run *TeaboxRun) GetErrorAction() func(class string, err error) {
+	return run.onError
+}
+
+// GetLandingPage of the run
+func (run *TeaboxRun) GetLandingPage() teawidgets.TeaboxLandingWindow {
+	return run.lander
+}
+
+// GetPanelName returns the name of the landing page on the forms panel
+func (run *TeaboxRun) GetPanelName() string {
+	return run.panel
+}
+
+// NewTeaboxJob constructor. The job takes over the next run of the module, and starts
+// the Unix socket server of the run.
+func NewTeaboxJob(formPanel *TeaFormsPanel) (*TeaboxJob, error) {
+	run, err := formPanel.TakeRun()
+	if err != nil {
+		return nil, err
+	}
+
+	// Forms are done, the run continues on its own socket
+	if err := formPanel.StopListener(); err != nil {
+		teabox.AddToFile(teaboxlib.LOG_FILENAME, fmt.Sprintf("Error: unable to stop the listener of the forms: %s", err.Error()))
+	}
+
+	job := &TeaboxJob{
+		formPanel: formPanel,
+		record:    teaboxlib.NewTeaboxJobRecord(formPanel.GetModuleConfig().GetTitle()),
+		run:       run,
+		server:    teaboxlib.NewTeaboxSocketServer(),
+		state:     JOB_RUNNING,
+		onChange:  func() {},
+		done:      make(chan struct{}),
+	}
+	job.attached = sync.NewCond(&job.mtx)
+	run.GetLandingPage().SetForegroundActions(func() bool { return !job.IsBackground() }, job.WaitForeground)
+
+	job.server.SetAuth(run.GetAuth()).SetOnErrorAction(run.GetErrorAction()).AddLocalAction(run.GetLandingPage().GetWindowAction(), formPanel.GetSessionAction(), formPanel.GetDialogAction(job))
+	if err := job.server.Start(run.GetSocketPath()); err != nil {
+		formPanel.RemovePanel(run.GetPanelName())
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// GetId of the job, which is the one of its history record
+func (job *TeaboxJob) GetId() string {
+	return job.record.GetId()
+}
+
+// GetRecord of the job for the history
+func (job *TeaboxJob) GetRecord() *teaboxlib.TeaboxJobRecord {
+	return job.record
+}
+
+// GetFormPanel returns the forms panel of the module
+func (job *TeaboxJob) GetFormPanel() *TeaFormsPanel {
+	return job.formPanel
+}
+
+// GetLandingPage of the job
+func (job *TeaboxJob) GetLandingPage() teawidgets.TeaboxLandingWindow {
+	return job.run.GetLandingPage()
+}
+
+// GetPanelName returns the name of the landing page on the forms panel
+func (job *TeaboxJob) GetPanelName() string {
+	return job.run.GetPanelName()
+}
+
+// GetSocketPath returns the path of the Unix socket of the job, while it is running
+func (job *TeaboxJob) GetSocketPath() string {
+	return job.server.GetSocketPath()
+}
+
+// GetEnv returns the environment, which is added to the commands of the job
+func (job *TeaboxJob) GetEnv() []string {
+	return job.run.GetEnv()
+}
+
+// GetState of the job
+func (job *TeaboxJob) GetState() int {
+	job.mtx.Lock()
+	defer job.mtx.Unlock()
+
+	return job.state
+}
+
+// IsBackground returns true, if the job is not shown
+func (job *TeaboxJob) IsBackground() bool {
+	job.mtx.Lock()
+	defer job.mtx.Unlock()
+
+	return job.background
+}
+
+// IsCancelled returns true, if the job was cancelled from the jobs list
+func (job *TeaboxJob) IsCancelled() bool {
+	job.mtx.Lock()
+	defer job.mtx.Unlock()
+
+	return job.cancelled
+}
+
+// Done returns a channel, which is closed once the job is cancelled or finished
+func (job *TeaboxJob) Done() <-chan struct{} {
+	return job.done
+}
+
+// Close the channel of Done, if it is not yet. Must be called with the lock held.
+func (job *TeaboxJob) closeDone() {
+	select {
+	case <-job.done:
+	default:
+		close(job.done)
+	}
+}
+
+// SetBackground sends the job to the background, or brings it to the foreground
+func (job *TeaboxJob) SetBackground(background bool) *TeaboxJob {
+	job.mtx.Lock()
+	defer job.mtx.Unlock()
+
+	job.background = background
+	job.attached.Broadcast()
+
+	return job
+}
+
+// SetOnChangeAction is called, when the state of the job changes
+func (job *TeaboxJob) SetOnChangeAction(action func()) *TeaboxJob {
+	job.onChange = action
+	return job
+}
+
+// WaitForeground blocks until the job is in the foreground, as the user is needed, e.g. to answer
+// a question. Returns false, if the job was cancelled meanwhile.
+func (job *TeaboxJob) WaitForeground() bool {
+	job.mtx.Lock()
+	waiting := job.background && !job.cancelled
+	if waiting {
+		job.state = JOB_WAITING
+	}
+	job.mtx.Unlock()
+
+	if waiting {
+		job.onChange()
+	}
+
+	job.mtx.Lock()
+	defer job.mtx.Unlock()
+
+	for job.background && !job.cancelled {
+		job.attached.Wait()
+	}
+	if job.state == JOB_WAITING {
+		job.state = JOB_RUNNING
+	}
+
+	return !job.cancelled
+}
+
+// Cancel the job. Its running command is terminated, and the rest of the chain does not run.
+func (job *TeaboxJob) Cancel() {
+	job.mtx.Lock()
+	if job.state == JOB_FINISHED {
+		job.mtx.Unlock()
+		return
+	}
+	job.cancelled = true
+	job.attached.Broadcast()
+	job.closeDone()
+	job.mtx.Unlock()
+
+	job.run.GetLandingPage().Cancel()
+}
+
+// Finish the job with the function, which shows its result. It is shown right away, if the job
+// is in the foreground, otherwise once it is attached.
+func (job *TeaboxJob) Finish(result func()) {
+	job.mtx.Lock()
+	job.state = JOB_FINISHED
+	job.result = result
+	background := job.background
+	job.closeDone()
+	job.mtx.Unlock()
+
+	job.onChange()
+	if !background {
+		result()
+	}
+}
+
+// ShowResult of the finished job
+func (job *TeaboxJob) ShowResult() {
+	job.mtx.Lock()
+	result := job.result
+	job.mtx.Unlock()
+
+	if result != nil {
+		result()
+	}
+}
+
+// Stop the Unix socket server of the job, once its result is seen
+func (job *TeaboxJob) Stop() error {
+	if job.server.IsRunning() {
+		return job.server.Stop()
+	}
+	return nil
+}
+
+// String describes the job for the jobs list
+func (job *TeaboxJob) String() string {
+	state := "running"
+	switch job.GetState() {
+	case JOB_WAITING:
+		state = "waiting for you"
+	case JOB_FINISHED:
+		state = "finished"
+	}
+
+	return fmt.Sprintf("%s  %-30s  %s", job.record.GetStarted().Format("15:04:05"), job.record.GetModuleTitle(), state)
+}
+
+// Unique id of a run of a module, for its socket and landing window
+func (taf *TeaboxArgsForm) newRunId() string {
+	taf.jobsMtx.Lock()
+	defer taf.jobsMtx.Unlock()
+
+	taf.jobsStarted++
+	return fmt.Sprintf("%d", taf.jobsStarted)
+}
+
+// Start a module, either showing its landing page, or in the background
+func (taf *TeaboxArgsForm) startJob(formPanel *TeaFormsPanel, rerun *teaboxlib.TeaboxJobRecord, background bool) {
+	job, err := NewTeaboxJob(formPanel)
+	if err != nil {
+		alert, panelPtr := taf.workspace.GetSeverityPopup(teaboxlib.RESULT_ALERT)
+		alert.SetTitle(fmt.Sprintf("%s: Module Error", formPanel.GetModuleConfig().GetTitle()))
+		alert.SetTextAutofill(false)
+		alert.SetMessage(fmt.Sprintf("Unable to start listener:\n%s", err.Error()))
+		alert.SetOnConfirmAction(func() {
+			taf.workspace.HidePanel(panelPtr)
+			formPanel.ShowForm(0)
+		})
+		taf.workspace.ShowPanel(panelPtr)
+		alert.SetFocus(1)
+		teabox.GetTeaboxApp().SetFocus(alert)
+		return
+	}
+	job.SetOnChangeAction(func() {
+		teabox.GetTeaboxApp().QueueUpdateDraw(taf.updateJobs)
+	})
+
+	taf.jobsMtx.Lock()
+	taf.jobs = append(taf.jobs, job)
+	taf.jobsMtx.Unlock()
+
+	if background {
+		job.SetBackground(true)
+		formPanel.ShowForm(0)
+		taf.ShowIntroScreen()
+		GetTeaboxMainWindow().GetMainMenu().FocusCurrentMenu()
+	} else {
+		taf.attachJob(job)
+	}
+	taf.updateJobs()
+
+	go taf.runModule(job, rerun)
+}
+
+// Show the landing page of the job, and its result, if it is finished
+func (taf *TeaboxArgsForm) attachJob(job *TeaboxJob) {
+	taf.jobsMtx.Lock()
+	taf.foreground = job
+	taf.jobsMtx.Unlock()
+
+	taf.allModulesForms.SetCurrentPanel(job.GetRecord().GetModuleTitle())
+	job.GetFormPanel().ShowLandingWindow(job)
+	job.SetBackground(false)
+	taf.updateJobs()
+
+	if job.GetState() == JOB_FINISHED {
+		job.ShowResult()
+	}
+}
+
+// Send the job in the foreground to the background, if there is any
+func (taf *TeaboxArgsForm) detachJob() {
+	taf.jobsMtx.Lock()
+	job := taf.foreground
+	taf.foreground = nil
+	taf.jobsMtx.Unlock()
+
+	if job != nil {
+		job.SetBackground(true)
+		job.GetFormPanel().ShowForm(0)
+	}
+}
+
+// Forget the job, once its result was seen
+func (taf *TeaboxArgsForm) closeJob(job *TeaboxJob) {
+	taf.jobsMtx.Lock()
+	for idx, j := range taf.jobs {
+		if j == job {
+			taf.jobs = append(taf.jobs[:idx], taf.jobs[idx+1:]...)
+			break
+		}
+	}
+	if taf.foreground == job {
+		taf.foreground = nil
+	}
+	taf.jobsMtx.Unlock()
+
+	if err := job.Stop(); err != nil {
+		teabox.AddToFile(teaboxlib.LOG_FILENAME, fmt.Sprintf("Error: unable to stop the listener of the job: %s", err.Error()))
+	}
+	job.GetFormPanel().StopLandingWindow(job)
+	taf.updateJobs()
+}
+
+// ShowJobs list, sending the job in the foreground to the background
+func (taf *TeaboxArgsForm) ShowJobs() {
+	taf.detachJob()
+	taf.updateJobs()
+	taf.allModulesForms.SetCurrentPanel(teawidgets.JOBS_WINDOW_COMMON)
+	teabox.GetTeaboxApp().SetFocus(taf.jobsWindow)
+}
+
+// Get a job by its index in the list. Returns nil, if there is none.
+func (taf *TeaboxArgsForm) getJob(idx int) *TeaboxJob {
+	taf.jobsMtx.Lock()
+	defer taf.jobsMtx.Unlock()
+
+	if idx < 0 || idx >= len(taf.jobs) {
+		return nil
+	}
+	return taf.jobs[idx]
+}
+
+// Update the jobs list and the summary of them in the header
+func (taf *TeaboxArgsForm) updateJobs() {
+	taf.jobsMtx.Lock()
+	running, finished := 0, 0
+	jobs := []string{}
+	for _, job := range taf.jobs {
+		jobs = append(jobs, job.String())
+		if job.GetState() == JOB_FINISHED {
+			finished++
+		} else {
+			running++
+		}
+	}
+	taf.jobsMtx.Unlock()
+
+	taf.jobsWindow.Load(jobs)
+	if running+finished == 0 {
+		taf.workspace.SetStatus("F2: Jobs")
+	} else {
+		taf.workspace.SetStatus(fmt.Sprintf("F2: Jobs (%d running, %d finished)", running, finished))
+	}
+}
+
+// Ask to confirm cancelling of a job
+func (taf *TeaboxArgsForm) cancelJob(job *TeaboxJob) {
+	popup := taf.workspace.questionPopup
+	popup.SetTitle("Cancel")
+	popup.SetTextAutofill(false)
+	popup.SetMessage(fmt.Sprintf("Cancel %s?", job.GetRecord().GetModuleTitle()))
+	popup.SetOnConfirmAction(func() {
+		taf.workspace.HidePanel("_question-popup")
+		job.Cancel()
+		teabox.GetTeaboxApp().SetFocus(taf.jobsWindow)
+	})
+	popup.SetOnCancelAction(func() {
+		taf.workspace.HidePanel("_question-popup")
+		teabox.GetTeaboxApp().SetFocus(taf.jobsWindow)
+	})
+
+	taf.workspace.ShowPanel("_question-popup")
+	popup.SetFocus(1)
+	teabox.GetTeaboxApp().SetFocus(popup)
+}
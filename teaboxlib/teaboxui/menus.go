@@ -77,10 +77,20 @@ func (tm *TeaboxMenu) createMenu(title string) *crtview.List {
 	return menuStub
 }
 
+// Returns a marker for a module, which configuration is broken
+func (tm *TeaboxMenu) getBrokenSuffix(c teaboxlib.TeaConfComponent) string {
+	if mod, ok := c.(*teaboxlib.TeaConfModule); ok && len(mod.GetErrors()) > 0 {
+		return teaboxlib.LABEL_BROKEN
+	}
+	return ""
+}
+
 func (tm *TeaboxMenu) makeSubmenu(mod teaboxlib.TeaConfComponent) {
 	menu := tm.createMenu(mod.GetTitle())
 	for _, c := range mod.GetChildren() {
-		menu.AddItem(crtview.NewListItem(fmt.Sprintf("%-"+strconv.Itoa(teaboxlib.MAIN_MENU_WIDTH-2)+"s", c.GetTitle())))
+		item := crtview.NewListItem(fmt.Sprintf("%-"+strconv.Itoa(teaboxlib.MAIN_MENU_WIDTH-2)+"s", c.GetTitle()+tm.getBrokenSuffix(c)))
+		item.SetReference(c)
+		menu.AddItem(item)
 	}
 
 	// Spacer
@@ -113,6 +123,10 @@ func (tm *TeaboxMenu) Init() TeaboxWindow {
 		ref := li.GetReference().(teaboxlib.TeaConfComponent)
 		if ref.GetTitle() == teaboxlib.LABEL_EXIT {
 			teabox.GetTeaboxApp().Stop("And remember: have a lot of fun!")
+		} else if ref.GetTitle() == teaboxlib.LABEL_HISTORY {
+			GetTeaboxMainWindow().ShowHistory()
+		} else if ref.GetTitle() == teaboxlib.LABEL_SHELL {
+			go GetTeaboxMainWindow().RunShell() // UI is suspended from the event loop, so not within it
 		} else if ref.IsGroupContainer() {
 			tm.ShowSubmenu(ref.GetTitle())
 		} else if ref.GetType() == "module" {
@@ -120,19 +134,24 @@ func (tm *TeaboxMenu) Init() TeaboxWindow {
 		}
 	})
 
-	for idx, mod := range teabox.GetTeaboxApp().GetGlobalConfig().GetModuleStructure() {
+	separated := false
+	for _, mod := range teabox.GetTeaboxApp().GetGlobalConfig().GetModuleStructure() {
 		suff := ""
 		if mod.IsGroupContainer() || mod.GetGroup() != "" {
 			tm.makeSubmenu(mod)
 			suff = teaboxlib.LABEL_MORE
 		}
-		if mod.GetTitle() == teaboxlib.LABEL_EXIT {
-			tm.items.AddItem(crtview.NewListItem(strings.Repeat(teaboxlib.LABEL_SEP, teaboxlib.MAIN_MENU_WIDTH-2)))
-			tm.items.SetItemEnabled(idx, false)
-			suff = "" // reset suffix for exit
+		if mod.GetType() == "command" {
+			// Separator goes before the first of history, shell and exit
+			if !separated {
+				tm.items.AddItem(crtview.NewListItem(strings.Repeat(teaboxlib.LABEL_SEP, teaboxlib.MAIN_MENU_WIDTH-2)))
+				tm.items.SetItemEnabled(tm.items.GetItemCount()-1, false)
+				separated = true
+			}
+			suff = "" // reset suffix for the built-in entries
 		}
 
-		item := crtview.NewListItem(fmt.Sprintf("%-"+strconv.Itoa(teaboxlib.MAIN_MENU_WIDTH-2)+"s", mod.GetTitle()+suff))
+		item := crtview.NewListItem(fmt.Sprintf("%-"+strconv.Itoa(teaboxlib.MAIN_MENU_WIDTH-2)+"s", mod.GetTitle()+suff+tm.getBrokenSuffix(mod)))
 		item.SetReference(mod)
 		tm.items.AddItem(item)
 	}
@@ -1,24 +1,35 @@
 package teaboxui
 
 import (
-	"strings"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/isbm/crtview"
 	"github.com/isbm/crtview/crtwin"
+	"github.com/isbm/crtview/crtwin/crtforms"
 	"gitlab.com/isbm/teabox"
 	"gitlab.com/isbm/teabox/teaboxlib"
+	"gitlab.com/isbm/teabox/teaboxlib/teaboxui/teawidgets"
 )
 
 const (
 	MAIN_WINDOW   = "main"
 	SUBMENU_POPUP = "submenu"
+
+	VIEW_OUTPUT_HINT = "Tab to \"View full output\" to see it all"
 )
 
 type TeaboxWorkspacePanels struct {
-	alertPopup   *crtwin.ModalDialog
-	warningPopup *crtwin.ModalDialog
-	infoPopup    *crtwin.ModalDialog
+	alertPopup    *crtwin.ModalDialog
+	warningPopup  *crtwin.ModalDialog
+	infoPopup     *crtwin.ModalDialog
+	questionPopup *crtwin.ModalDialog
+	outputView    *teawidgets.TeaLogView
+	status        string
+	dialogMtx     sync.Mutex
 
 	container *crtview.Flex
 	*crtview.Panels
@@ -50,8 +61,9 @@ func (tbp *TeaboxWorkspacePanels) initDialogs() *TeaboxWorkspacePanels {
 	tbp.infoPopup = crtwin.NewModalDialog(crtwin.DIALOG_OK | crtwin.DIALOG_TYPE_ALT_INFO)
 	tbp.warningPopup = crtwin.NewModalDialog(crtwin.DIALOG_OK | crtwin.DIALOG_TYPE_WARNING)
 	tbp.alertPopup = crtwin.NewModalDialog(crtwin.DIALOG_OK | crtwin.DIALOG_TYPE_ALERT)
+	tbp.questionPopup = crtwin.NewModalDialog(crtwin.DIALOG_YES_NO | crtwin.DIALOG_TYPE_WARNING)
 
-	for _, alert := range []*crtwin.ModalDialog{tbp.infoPopup, tbp.warningPopup, tbp.alertPopup} {
+	for _, alert := range []*crtwin.ModalDialog{tbp.infoPopup, tbp.warningPopup, tbp.alertPopup, tbp.questionPopup} {
 		alert.SetTitle("")
 		alert.SetMessage("")
 		alert.SetButtonsAlign(crtview.AlignCenter)
@@ -59,15 +71,96 @@ func (tbp *TeaboxWorkspacePanels) initDialogs() *TeaboxWorkspacePanels {
 		alert.SetOnConfirmAction(func() {
 			tbp.SetCurrentPanel("main")
 		})
+		alert.SetOnCancelAction(func() {
+			tbp.SetCurrentPanel("main")
+		})
 	}
 
 	tbp.AddPanel("_info-popup", tbp.infoPopup, false, false)
 	tbp.AddPanel("_alert-popup", tbp.alertPopup, false, false)
 	tbp.AddPanel("_warn-popup", tbp.warningPopup, false, false)
+	tbp.AddPanel("_question-popup", tbp.questionPopup, false, false)
+
+	// Viewer of the whole output of a module
+	tbp.outputView = teawidgets.NewTeaLogView()
+	tbp.outputView.SetBorder(true)
+	tbp.outputView.SetBorderPadding(0, 0, 1, 1)
+	tbp.outputView.SetBackgroundColor(tcell.ColorLightGray)
+	tbp.outputView.SetTextColor(tcell.ColorBlack)
+	tbp.outputView.SetBorderColor(teaboxlib.FORM_BORDER_SELECTED)
+	tbp.outputView.SetBorderColorFocused(teaboxlib.FORM_BORDER_SELECTED)
+	tbp.outputView.SetDynamicColors(true)
+	tbp.outputView.SetSkipCursorReturn(true)
+
+	viewer := crtview.NewFlex()
+	viewer.SetDirection(crtview.FlexRow)
+	viewer.AddItem(crtview.NewBox(), 1, 0, false)
+	viewer.AddItem(tbp.outputView, 0, 1, true)
+	viewer.AddItem(crtview.NewBox(), 1, 0, false)
+	tbp.AddPanel("_output-view", viewer, true, false)
 
 	return tbp
 }
 
+// GetSeverityPopup returns a popup and its panel name for the severity of a command result
+func (tbp *TeaboxWorkspacePanels) GetSeverityPopup(severity string) (*crtwin.ModalDialog, string) {
+	switch severity {
+	case teaboxlib.RESULT_WARNING:
+		return tbp.warningPopup, "_warn-popup"
+	case teaboxlib.RESULT_ALERT:
+		return tbp.alertPopup, "_alert-popup"
+	default:
+		return tbp.infoPopup, "_info-popup"
+	}
+}
+
+// ShowOutput shows the whole output of a module in a scrollable viewer. Once it is closed
+// with Esc or Enter, "onClose" is called.
+func (tbp *TeaboxWorkspacePanels) ShowOutput(title, output string, onClose func()) {
+	tbp.outputView.SetTitle(fmt.Sprintf(" %s (/: search, n: next, s: save, Esc: close) ", title))
+	tbp.outputView.SetText(output)
+	tbp.outputView.ScrollToEnd()
+	tbp.outputView.SetDoneFunc(func(key tcell.Key) {
+		tbp.HidePanel("_output-view")
+		onClose()
+	})
+
+	tbp.ShowPanel("_output-view")
+	teabox.GetTeaboxApp().SetFocus(tbp.outputView)
+}
+
+// AddViewOutputButton adds a "View full output" button to the popup, which is shown again
+// after the output is closed. Button is removed with RemoveViewOutputButton.
+func (tbp *TeaboxWorkspacePanels) AddViewOutputButton(popup *crtwin.ModalDialog, panelPtr, title, output string) {
+	// Popup is as wide as its message, so the hint also makes room for both buttons
+	if msg, ok := popup.GetFormItem(0).(*crtforms.FormTextView); ok {
+		msg.SetText(msg.GetText(false) + "\n\n" + VIEW_OUTPUT_HINT)
+	}
+
+	popup.AddButton("View full output", func() {
+		tbp.HidePanel(panelPtr)
+		tbp.ShowOutput(title, output, func() {
+			tbp.ShowPanel(panelPtr)
+			teabox.GetTeaboxApp().SetFocus(popup)
+		})
+	})
+}
+
+// RemoveViewOutputButton removes the "View full output" button from the popup, if any
+func (tbp *TeaboxWorkspacePanels) RemoveViewOutputButton(popup *crtwin.ModalDialog) {
+	if idx := popup.GetButtonIndex("View full output"); idx >= 0 {
+		popup.RemoveButton(idx)
+	}
+}
+
+// SetStatus text, which is shown at the right side of the header
+func (tbp *TeaboxWorkspacePanels) SetStatus(status string) {
+	tbp.Lock()
+	defer tbp.Unlock()
+
+	tbp.status = status
+}
+
 func (tbp *TeaboxWorkspacePanels) GetContainer() *crtview.Flex {
 	return tbp.container
 }
@@ -106,6 +199,12 @@ func (tbp *TeaboxWorkspacePanels) Draw(screen tcell.Screen) {
 	for i, c := range tbp.GetTitle() {
 		screen.SetContent(i+1, 0, c, nil, hdr)
 	}
+
+	// Status
+	status := []rune(tbp.status)
+	for i, c := range status {
+		screen.SetContent(w-len(status)-1+i, 0, c, nil, hdr)
+	}
 }
 
 var _teaboxMainWindowRef *TeaboxMainWindow
@@ -135,11 +234,31 @@ func NewTeaboxMainWindow() *TeaboxMainWindow {
 	tmw := new(TeaboxMainWindow)
 	teabox.GetTeaboxApp().EnableMouse(true)
 	teabox.GetTeaboxApp().SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// Embedded terminal takes all the keys while its command runs, even arrows and Ctrl+C
+		if grabber, ok := teabox.GetTeaboxApp().GetFocus().(teawidgets.TeaboxKeyGrabber); ok && grabber.IsGrabbingKeys() {
+			grabber.InputHandler()(event, func(p crtview.Primitive) {
+				teabox.GetTeaboxApp().SetFocus(p)
+			})
+			return nil
+		}
+
 		switch event.Key() {
-		case tcell.KeyRight:
-			teabox.GetTeaboxApp().SetFocus(tmw.formWindow.GetWidget())
-		case tcell.KeyLeft:
-			tmw.menu.FocusCurrentMenu()
+		case tcell.KeyF2:
+			// Jobs are listed only from the workspace, not over a popup
+			if name, _ := tmw.p.GetFrontPanel(); name == MAIN_WINDOW {
+				tmw.formWindow.ShowJobs()
+			}
+			return nil
+		case tcell.KeyRight, tcell.KeyLeft:
+			// Arrows move between the menu and the forms only in the workspace, a popup keeps them
+			if name, _ := tmw.p.GetFrontPanel(); name != MAIN_WINDOW {
+				return event
+			}
+			if event.Key() == tcell.KeyRight {
+				teabox.GetTeaboxApp().SetFocus(tmw.formWindow.GetWidget())
+			} else {
+				tmw.menu.FocusCurrentMenu()
+			}
 		default:
 			//fmt.Println(event.Key())
 		}
@@ -150,7 +269,8 @@ func NewTeaboxMainWindow() *TeaboxMainWindow {
 	// Whole workspace
 	tmw.menu = NewTeaboxMenu()
 	tmw.menu.SetOnSelectedFunc(func(i int, li *crtview.ListItem) {
-		tmw.formWindow.ShowModuleForm(strings.TrimSpace(li.GetMainText()))
+		// Menu labels may have markers, so the module is found by its title
+		tmw.formWindow.ShowModuleForm(li.GetReference().(teaboxlib.TeaConfComponent).GetTitle())
 	})
 
 	tmw.p = NewTeaboxWorkspacePanels(tmw.title)
@@ -170,3 +290,30 @@ func (tmw *TeaboxMainWindow) GetContent() crtview.Primitive {
 func (tmw *TeaboxMainWindow) GetMainMenu() *TeaboxMenu {
 	return tmw.menu
 }
+
+// ShowHistory of the past jobs in the workspace
+func (tmw *TeaboxMainWindow) ShowHistory() {
+	tmw.formWindow.ShowHistory()
+}
+
+// RunShell suspends the UI and runs the system shell on the terminal, until the user quits it
+func (tmw *TeaboxMainWindow) RunShell() {
+	err := teawidgets.RunSuspended(exec.Command(teaboxlib.SYSTEM_SHELL), 0)
+
+	var exitErr *exec.ExitError
+	if err == nil || errors.As(err, &exitErr) {
+		return // Exit code of the shell is the one of its last command, nothing to report
+	}
+
+	alert, panelPtr := tmw.p.GetSeverityPopup(teaboxlib.RESULT_ALERT)
+	alert.SetTitle("Shell Error")
+	alert.SetTextAutofill(false)
+	alert.SetMessage(fmt.Sprintf("Unable to start %s:\n%s", teaboxlib.SYSTEM_SHELL, err.Error()))
+	alert.SetOnConfirmAction(func() {
+		tmw.p.HidePanel(panelPtr)
+		tmw.menu.FocusCurrentMenu()
+	})
+	tmw.p.ShowPanel(panelPtr)
+	teabox.GetTeaboxApp().SetFocus(alert.GetButton(0))
+	teabox.GetTeaboxApp().Draw()
+}
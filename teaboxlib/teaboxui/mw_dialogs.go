@@ -0,0 +1,163 @@
+package teaboxui
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/isbm/crtview"
+	"github.com/isbm/crtview/crtwin"
+	"gitlab.com/isbm/teabox"
+	"gitlab.com/isbm/teabox/teaboxlib"
+)
+
+// Panel of the dialog, which a module asks the user with
+const MODULE_DIALOG = "_module-dialog"
+
+// Answer of the user to a dialog of a module
+type dialogAnswer struct {
+	value string
+	ok    bool
+}
+
+// Send the answer, unless the dialog is already answered
+func answerDialog(answer chan dialogAnswer, value string, ok bool) {
+	select {
+	case answer <- dialogAnswer{value: value, ok: ok}:
+	default:
+	}
+}
+
+// Show a dialog of a module over everything and block until the user answers it, or it is cancelled,
+// as nobody waits for the answer anymore. Only one dialog is shown at a time, the others wait for it.
+// This must be called outside of the UI event loop.
+func (tbp *TeaboxWorkspacePanels) runDialog(dialog crtview.Primitive, answer chan dialogAnswer, cancel <-chan struct{}) (string, bool) {
+	tbp.dialogMtx.Lock()
+	defer tbp.dialogMtx.Unlock()
+
+	// Cancelled, while waiting for the other dialog
+	select {
+	case <-cancel:
+		return "", false
+	default:
+	}
+
+	focused := teabox.GetTeaboxApp().GetFocus()
+	tbp.AddPanel(MODULE_DIALOG, dialog, false, true)
+	teabox.GetTeaboxApp().SetFocus(dialog)
+	teabox.GetTeaboxApp().Draw()
+
+	var a dialogAnswer
+	select {
+	case a = <-answer:
+	case <-cancel:
+	}
+
+	tbp.RemovePanel(MODULE_DIALOG)
+	if focused != nil {
+		teabox.GetTeaboxApp().SetFocus(focused)
+	}
+	teabox.GetTeaboxApp().Draw()
+
+	return a.value, a.ok
+}
+
+// Create a dialog with the OK and Cancel buttons. Esc cancels it as well.
+func (tbp *TeaboxWorkspacePanels) newInputDialog(title string, width int, answer chan dialogAnswer, value func() string) *crtwin.DialogWindow {
+	if len(title)+6 > width {
+		width = len(title) + 6
+	}
+
+	dialog := crtwin.NewDialogWindow()
+	dialog.SetTitle(title)
+	dialog.SetCentered(true)
+	dialog.SetSize(width, 9)
+	dialog.SetBackgroundColor(crtview.Styles.InfoDialogBackgroundColor)
+	dialog.SetBorderColor(crtview.Styles.InfoDialogBorderColor)
+	dialog.SetBorderColorFocused(crtview.Styles.InfoDialogBorderColor)
+	dialog.SetTitleColor(crtview.Styles.InfoDialogBorderColor)
+	dialog.SetLabelColor(crtview.Styles.InfoDialogTextColor)
+	dialog.SetFieldBackgroundColor(teaboxlib.FORM_FIELD_BACKGROUND)
+	dialog.SetFieldBackgroundColorFocused(teaboxlib.FORM_FIELD_BACKGROUND_FOCUSED)
+	dialog.SetFieldTextColor(teaboxlib.FORM_FIELD_TEXT)
+	dialog.SetFieldTextColorFocused(teaboxlib.FORM_FIELD_TEXT)
+	dialog.SetButtonBackgroundColor(teaboxlib.FORM_BUTTON_BACKGROUND)
+	dialog.SetButtonBackgroundColorFocused(teaboxlib.FORM_BUTTON_BACKGROUND_SELECTED)
+	dialog.SetButtonTextColor(teaboxlib.FORM_BUTTON_TEXT)
+	dialog.SetButtonTextColorFocused(teaboxlib.FORM_BUTTON_TEXT_SELECTED)
+	dialog.SetButtonsAlign(crtview.AlignCenter)
+
+	dialog.AddButton("OK", func() { answerDialog(answer, value(), true) })
+	dialog.AddButton("Cancel", func() { answerDialog(answer, "", false) })
+	dialog.SetCancelFunc(func() { answerDialog(answer, "", false) })
+
+	return dialog
+}
+
+// Confirm asks a module's yes/no question. It returns the answer, and false, if the user cancelled it with Esc,
+// or it was cancelled by closing the channel.
+func (tbp *TeaboxWorkspacePanels) Confirm(title, question string, cancel <-chan struct{}) (bool, bool) {
+	answer := make(chan dialogAnswer, 1)
+
+	popup := crtwin.NewModalDialog(crtwin.DIALOG_YES_NO | crtwin.DIALOG_TYPE_WARNING)
+	popup.SetTitle(title)
+	popup.SetMessage(question)
+	popup.SetTextAutofill(false)
+	popup.SetButtonsAlign(crtview.AlignCenter)
+	popup.SetOnConfirmAction(func() { answerDialog(answer, teaboxlib.DIALOG_YES, true) })
+	popup.SetOnCancelAction(func() { answerDialog(answer, teaboxlib.DIALOG_NO, true) })
+	popup.SetCancelFunc(func() { answerDialog(answer, "", false) })
+	popup.SetFocus(1) // Message is the first item, so "Yes" button is the second
+
+	value, ok := tbp.runDialog(popup, answer, cancel)
+	return value == teaboxlib.DIALOG_YES, ok
+}
+
+// Input asks a module's text value, which is masked, if it is a secret. It returns the value, and false,
+// if the user or the channel cancelled it.
+func (tbp *TeaboxWorkspacePanels) Input(title, label, value string, secret bool, cancel <-chan struct{}) (string, bool) {
+	answer := make(chan dialogAnswer, 1)
+
+	field := crtview.NewInputField()
+	field.SetLabel(label)
+	field.SetText(value)
+	if secret {
+		field.SetMaskCharacter('*')
+	}
+	field.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			answerDialog(answer, field.GetText(), true)
+		}
+	})
+
+	dialog := tbp.newInputDialog(title, len(label)+40, answer, field.GetText)
+	dialog.AddFormItem(field)
+
+	return tbp.runDialog(dialog, answer, cancel)
+}
+
+// Choose asks a module's choice of the options. It returns the chosen option, and false, if the user
+// or the channel cancelled it.
+func (tbp *TeaboxWorkspacePanels) Choose(title, label string, options []string, cancel <-chan struct{}) (string, bool) {
+	answer := make(chan dialogAnswer, 1)
+
+	width := 0
+	for _, opt := range options {
+		if len(opt) > width {
+			width = len(opt)
+		}
+	}
+
+	choice := crtview.NewDropDown()
+	choice.SetLabel(label)
+	choice.SetOptionsSimple(nil, options...)
+	choice.SetCurrentOption(0)
+	choice.GetListObject().SetBackgroundColor(teaboxlib.FORM_FIELD_BACKGROUND_DARKER)
+
+	dialog := tbp.newInputDialog(title, len(label)+width+10, answer, func() string {
+		if _, opt := choice.GetCurrentOption(); opt != nil {
+			return opt.GetText()
+		}
+		return ""
+	})
+	dialog.AddFormItem(choice)
+
+	return tbp.runDialog(dialog, answer, cancel)
+}
@@ -0,0 +1,143 @@
+package teawidgets
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/isbm/crtview"
+	"gitlab.com/isbm/teabox/teaboxlib"
+)
+
+// TeaboxArgsHistoryWindow lists the past jobs, the latest first. Enter views a job, "r" runs it again.
+type TeaboxArgsHistoryWindow struct {
+	records []*teaboxlib.TeaboxJobRecord
+	onView  func(*teaboxlib.TeaboxJobRecord)
+	onRerun func(*teaboxlib.TeaboxJobRecord)
+	onClose func()
+
+	*crtview.List
+}
+
+func NewTeaboxArgsHistoryWindow() *TeaboxArgsHistoryWindow {
+	return (&TeaboxArgsHistoryWindow{List: crtview.NewList()}).init()
+}
+
+func (thw *TeaboxArgsHistoryWindow) init() *TeaboxArgsHistoryWindow {
+	thw.SetBackgroundColor(teaboxlib.WORKSPACE_BACKGROUND)
+	thw.SetBorder(true)
+	thw.SetFocusedBorderStyle(crtview.BorderSingle)
+	thw.SetBorderColor(teaboxlib.FORM_BORDER)
+	thw.SetBorderColorFocused(teaboxlib.FORM_BORDER_SELECTED)
+	thw.SetSelectedBackgroundColor(teaboxlib.MENU_ITEM_SELECTED)
+	thw.SetSelectedTextColor(teaboxlib.MENU_ITEM)
+	thw.SetTitle("History (Enter: view, r: run again, Esc: close)")
+	thw.ShowSecondaryText(false)
+	thw.SetWrapAround(false)
+
+	thw.SetSelectedFunc(func(idx int, item *crtview.ListItem) {
+		if rec := thw.getRecord(idx); rec != nil && thw.onView != nil {
+			thw.onView(rec)
+		}
+	})
+
+	return thw
+}
+
+// SetOnViewAction is called when a job is selected
+func (thw *TeaboxArgsHistoryWindow) SetOnViewAction(action func(*teaboxlib.TeaboxJobRecord)) *TeaboxArgsHistoryWindow {
+	thw.onView = action
+	return thw
+}
+
+// SetOnRerunAction is called when a job is asked to run again
+func (thw *TeaboxArgsHistoryWindow) SetOnRerunAction(action func(*teaboxlib.TeaboxJobRecord)) *TeaboxArgsHistoryWindow {
+	thw.onRerun = action
+	return thw
+}
+
+// SetOnCloseAction is called when the history is closed with Esc
+func (thw *TeaboxArgsHistoryWindow) SetOnCloseAction(action func()) *TeaboxArgsHistoryWindow {
+	thw.onClose = action
+	return thw
+}
+
+// Load the records to the list
+func (thw *TeaboxArgsHistoryWindow) Load(records []*teaboxlib.TeaboxJobRecord) {
+	thw.records = records
+	thw.Clear()
+
+	if len(records) == 0 {
+		thw.AddItem(crtview.NewListItem("No jobs were run yet"))
+		thw.SetItemEnabled(0, false)
+		return
+	}
+
+	for _, rec := range records {
+		status := rec.GetState()
+		if status != teaboxlib.JOB_STATE_CANCELLED {
+			status = fmt.Sprintf("%s (%d)", status, rec.GetExitCode())
+		}
+		thw.AddItem(crtview.NewListItem(crtview.Escape(fmt.Sprintf("%s  %-30s  %s",
+			rec.GetStarted().Format("2006-01-02 15:04:05"), rec.GetModuleTitle(), status))))
+	}
+}
+
+// Get a record of the list item. Returns nil, if there is none.
+func (thw *TeaboxArgsHistoryWindow) getRecord(idx int) *teaboxlib.TeaboxJobRecord {
+	if idx < 0 || idx >= len(thw.records) {
+		return nil
+	}
+	return thw.records[idx]
+}
+
+// InputHandler adds running a job again and closing to the usual list keys
+func (thw *TeaboxArgsHistoryWindow) InputHandler() func(event *tcell.EventKey, setFocus func(p crtview.Primitive)) {
+	handler := thw.List.InputHandler()
+	return func(event *tcell.EventKey, setFocus func(p crtview.Primitive)) {
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			if thw.onClose != nil {
+				thw.onClose()
+			}
+		case event.Key() == tcell.KeyRune && event.Rune() == 'r':
+			if rec := thw.getRecord(thw.GetCurrentItemIndex()); rec != nil && thw.onRerun != nil {
+				thw.onRerun(rec)
+			}
+		default:
+			handler(event, setFocus)
+		}
+	}
+}
+
+// DescribeJob returns a description of a past job with its commands and its output, for a log view
+func DescribeJob(rec *teaboxlib.TeaboxJobRecord) string {
+	out := []string{
+		fmt.Sprintf("Module:    %s", rec.GetModuleTitle()),
+		fmt.Sprintf("Started:   %s", rec.GetStarted().Format("2006-01-02 15:04:05")),
+		fmt.Sprintf("Finished:  %s (%s)", rec.GetFinished().Format("2006-01-02 15:04:05"),
+			rec.GetFinished().Sub(rec.GetStarted()).Round(time.Second)),
+		fmt.Sprintf("State:     %s", rec.GetState()),
+		fmt.Sprintf("Exit code: %d", rec.GetExitCode()),
+		"",
+	}
+
+	for _, c := range rec.GetCommands() {
+		state := c.GetState()
+		if state == teaboxlib.JOB_STATE_DONE || state == teaboxlib.JOB_STATE_FAILED {
+			state = fmt.Sprintf("%s (%d)", state, c.GetExitCode())
+		}
+		out = append(out, fmt.Sprintf("%s: %s", c.GetTitle(), state),
+			fmt.Sprintf("  $ %s %s", c.GetCommandPath(), strings.Join(c.GetArguments(), " ")))
+	}
+
+	for idx := range out {
+		out[idx] = crtview.Escape(out[idx])
+	}
+	if rec.GetTranscript() != "" {
+		out = append(out, "", strings.Repeat(teaboxlib.LABEL_SEP, 40), "", rec.GetTranscript())
+	}
+
+	return strings.Join(out, "\n")
+}
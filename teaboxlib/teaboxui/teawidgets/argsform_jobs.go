@@ -0,0 +1,100 @@
+package teawidgets
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/isbm/crtview"
+	"gitlab.com/isbm/teabox/teaboxlib"
+)
+
+// TeaboxArgsJobsWindow lists the jobs, which are running or finished, but not yet seen.
+// Enter attaches to a job, "c" cancels it.
+type TeaboxArgsJobsWindow struct {
+	count    int
+	onAttach func(idx int)
+	onCancel func(idx int)
+	onClose  func()
+
+	*crtview.List
+}
+
+func NewTeaboxArgsJobsWindow() *TeaboxArgsJobsWindow {
+	return (&TeaboxArgsJobsWindow{List: crtview.NewList()}).init()
+}
+
+func (tjw *TeaboxArgsJobsWindow) init() *TeaboxArgsJobsWindow {
+	tjw.SetBackgroundColor(teaboxlib.WORKSPACE_BACKGROUND)
+	tjw.SetBorder(true)
+	tjw.SetFocusedBorderStyle(crtview.BorderSingle)
+	tjw.SetBorderColor(teaboxlib.FORM_BORDER)
+	tjw.SetBorderColorFocused(teaboxlib.FORM_BORDER_SELECTED)
+	tjw.SetSelectedBackgroundColor(teaboxlib.MENU_ITEM_SELECTED)
+	tjw.SetSelectedTextColor(teaboxlib.MENU_ITEM)
+	tjw.SetTitle("Jobs (Enter: attach, c: cancel, Esc: close)")
+	tjw.ShowSecondaryText(false)
+	tjw.SetWrapAround(false)
+
+	tjw.SetSelectedFunc(func(idx int, item *crtview.ListItem) {
+		if idx < tjw.count && tjw.onAttach != nil {
+			tjw.onAttach(idx)
+		}
+	})
+
+	return tjw
+}
+
+// SetOnAttachAction is called with the index of a job, when it is selected
+func (tjw *TeaboxArgsJobsWindow) SetOnAttachAction(action func(idx int)) *TeaboxArgsJobsWindow {
+	tjw.onAttach = action
+	return tjw
+}
+
+// SetOnCancelAction is called with the index of a job, when it is asked to be cancelled
+func (tjw *TeaboxArgsJobsWindow) SetOnCancelAction(action func(idx int)) *TeaboxArgsJobsWindow {
+	tjw.onCancel = action
+	return tjw
+}
+
+// SetOnCloseAction is called when the list is closed with Esc
+func (tjw *TeaboxArgsJobsWindow) SetOnCloseAction(action func()) *TeaboxArgsJobsWindow {
+	tjw.onClose = action
+	return tjw
+}
+
+// Load the descriptions of the jobs to the list, keeping the selection
+func (tjw *TeaboxArgsJobsWindow) Load(jobs []string) {
+	current := tjw.GetCurrentItemIndex()
+	tjw.count = len(jobs)
+	tjw.Clear()
+
+	if len(jobs) == 0 {
+		tjw.AddItem(crtview.NewListItem("No jobs are running"))
+		tjw.SetItemEnabled(0, false)
+		return
+	}
+
+	for _, job := range jobs {
+		tjw.AddItem(crtview.NewListItem(crtview.Escape(job)))
+	}
+	if current > 0 && current < len(jobs) {
+		tjw.SetCurrentItem(current)
+	}
+}
+
+// InputHandler adds cancelling a job and closing to the usual list keys
+func (tjw *TeaboxArgsJobsWindow) InputHandler() func(event *tcell.EventKey, setFocus func(p crtview.Primitive)) {
+	handler := tjw.List.InputHandler()
+	return func(event *tcell.EventKey, setFocus func(p crtview.Primitive)) {
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			if tjw.onClose != nil {
+				tjw.onClose()
+			}
+		case event.Key() == tcell.KeyRune && event.Rune() == 'c':
+			if idx := tjw.GetCurrentItemIndex(); idx < tjw.count && tjw.onCancel != nil {
+				tjw.onCancel(idx)
+			}
+		default:
+			handler(event, setFocus)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package teawidgets
 
 import (
+	"bytes"
 	"fmt"
-	"os/exec"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 	wzlib_logger "github.com/infra-whizz/wzlib/logger"
@@ -135,8 +138,11 @@ func (ld *TeaboxArgsLoadingWindow) GetSocketAcceptAction() func(*teaboxlib.Teabo
 			ld.SetStatus(call.GetString())
 		case teaboxlib.INIT_RESET:
 			ld.Reset()
+		default:
+			return ""
 		}
 
+		call.SetHandled()
 		teabox.GetTeaboxApp().Draw()
 		return ""
 	}
@@ -147,10 +153,44 @@ func (ld *TeaboxArgsLoadingWindow) SkipLoad() {
 	ld.action()
 }
 
-// Load form (call setup script)
-func (ld *TeaboxArgsLoadingWindow) Load(cmd string, args ...string) error {
+// Load form (call setup script) with the environment of the next run
+func (ld *TeaboxArgsLoadingWindow) Load(modcmd *teaboxlib.TeaConfModCommand, env []string, cmd string, args ...string) error {
 	go func() {
-		if output, err := exec.Command(cmd, args...).CombinedOutput(); err != nil {
+		// Setup command runs in the working directory, environment and timeout of the module command
+		output := new(bytes.Buffer)
+		c := modcmd.NewCommand(cmd, args...)
+		c.Env = append(c.Env, env...)
+		c.Stdout = output
+		c.Stderr = output
+		c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true} // Timeout terminates its children as well
+
+		err := c.Start()
+		if err == nil {
+			var timer *time.Timer
+			var mtx sync.Mutex
+			running := true
+			if modcmd.GetTimeout() > 0 {
+				timer = time.AfterFunc(modcmd.GetTimeout(), func() {
+					terminateGroup(c.Process.Pid, func() bool {
+						mtx.Lock()
+						defer mtx.Unlock()
+
+						return running
+					})
+				})
+			}
+
+			err = c.Wait()
+			mtx.Lock()
+			running = false
+			mtx.Unlock()
+
+			if timer != nil && !timer.Stop() {
+				err = fmt.Errorf("timed out after %s", modcmd.GetTimeout())
+			}
+		}
+
+		if err != nil {
 			teabox.GetTeaboxApp().Stop(fmt.Sprintf("Error: Failure while loading form from setup command.\nCommand: %s\nargs: %v\nSystem exit: %s\nError details: %s", cmd, args, err.Error(), output))
 		}
 
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"os"
 	"path"
 	"strings"
 
@@ -31,27 +30,36 @@ type TeaboxArgsMainWindow struct {
 	namedArg               map[string]*teaboxlib.TeaConfModArg
 	skipLoad               bool
 	confModCommand         *teaboxlib.TeaConfModCommand
+	env                    []string        // Environment of the signal calls, which is the one of the next run
+	hidden                 map[string]bool // Labels of the fields, hidden by the API. Their arguments are not passed.
+	disabled               map[string]bool // Labels of the fields, disabled by the API. Their arguments are not passed.
+	tabularRows            map[string]int  // Number of the rows of the tabular fields by their labels
 
 	*crtview.Form
 }
 
-func NewTeaboxArgsMainWindow(title, subtitle string) *TeaboxArgsMainWindow {
+// NewTeaboxArgsMainWindow constructor. Forms are told apart by the index of their command, as the titles
+// of the commands may repeat or be empty.
+func NewTeaboxArgsMainWindow(title, subtitle string, idx int) *TeaboxArgsMainWindow {
 	return (&TeaboxArgsMainWindow{
-		Form:       crtview.NewForm(),
-		title:      title,
-		subtitle:   subtitle,
-		flags:      []string{},
-		argset:     map[string]string{},
-		argindex:   []string{},
-		labeledArg: map[string]*teaboxlib.TeaConfModArg{},
-		namedArg:   map[string]*teaboxlib.TeaConfModArg{},
-		skipLoad:   false,
+		Form:        crtview.NewForm(),
+		cmdId:       fmt.Sprintf("%s - %d", title, idx),
+		title:       title,
+		subtitle:    subtitle,
+		flags:       []string{},
+		argset:      map[string]string{},
+		argindex:    []string{},
+		labeledArg:  map[string]*teaboxlib.TeaConfModArg{},
+		namedArg:    map[string]*teaboxlib.TeaConfModArg{},
+		hidden:      map[string]bool{},
+		disabled:    map[string]bool{},
+		tabularRows: map[string]int{},
+		skipLoad:    false,
 	}).init()
 }
 
 func (tmw *TeaboxArgsMainWindow) init() *TeaboxArgsMainWindow {
 	tmw.SetTitle(fmt.Sprintf("%s - %s", tmw.title, tmw.subtitle))
-	tmw.cmdId = fmt.Sprintf("%s - %s", tmw.title, tmw.subtitle)
 
 	tmw.SetBorder(true)
 
@@ -69,6 +77,12 @@ func (tmw *TeaboxArgsMainWindow) init() *TeaboxArgsMainWindow {
 	return tmw
 }
 
+// SetEnv adds the environment to the signal calls of the form
+func (tmw *TeaboxArgsMainWindow) SetEnv(env []string) *TeaboxArgsMainWindow {
+	tmw.env = env
+	return tmw
+}
+
 func (tmw *TeaboxArgsMainWindow) SkipLoad() bool {
 	return tmw.skipLoad
 }
@@ -89,6 +103,11 @@ func (tmw *TeaboxArgsMainWindow) GetId() string {
 	return tmw.cmdId
 }
 
+// GetSubtitle returns the title of the command of the form
+func (tmw *TeaboxArgsMainWindow) GetSubtitle() string {
+	return tmw.subtitle
+}
+
 // AddFlag adds a flag to the CLI command per a form.
 func (tmw *TeaboxArgsMainWindow) AddFlag(formid, flag string) *TeaboxArgsMainWindow {
 	if flag == "" {
@@ -171,6 +190,19 @@ func (tmw *TeaboxArgsMainWindow) RemoveArgument(formid, argname string) *TeaboxA
 //
 // All the data is ordered as it is described in the module configuration.
 func (tmw *TeaboxArgsMainWindow) GetCommandArguments(formid string) []string {
+	cargs, _ := tmw.commandArguments(false)
+	return cargs
+}
+
+// GetMaskedCommandArguments returns the same as GetCommandArguments, but the values of the secret
+// arguments, such as passwords, are replaced with a mask. It also returns true, if anything was masked.
+func (tmw *TeaboxArgsMainWindow) GetMaskedCommandArguments(formid string) ([]string, bool) {
+	return tmw.commandArguments(true)
+}
+
+func (tmw *TeaboxArgsMainWindow) commandArguments(mask bool) ([]string, bool) {
+	masked := false
+
 	// Get flags for this form, if any
 	cargs := append([]string{}, tmw.flags...) // copy
 
@@ -181,8 +213,16 @@ func (tmw *TeaboxArgsMainWindow) GetCommandArguments(formid string) []string {
 			continue
 		}
 
+		// Skip argument of a field, which is hidden or disabled
+		if label := tmw.namedArg[arg].GetWidgetLabel(); tmw.hidden[label] || tmw.disabled[label] {
+			continue
+		}
+
 		// Maybe skip argument, depending how on value conditions
 		val := tmw.argset[arg]
+		if val != "" && mask && tmw.isSecret(tmw.namedArg[arg]) {
+			val, masked = teaboxlib.JOB_SECRET_MASK, true
+		}
 		if val != "" {
 			val = fmt.Sprintf("%s=%s", arg, val)
 		} else if tmw.namedArg[arg].GetAttrs() == nil || !tmw.namedArg[arg].GetAttrs().HasOption("skip-empty") {
@@ -195,11 +235,60 @@ func (tmw *TeaboxArgsMainWindow) GetCommandArguments(formid string) []string {
 		}
 	}
 
-	return cargs
+	return cargs, masked
+}
+
+// GetSecrets returns the values of the secret arguments, which are set
+func (tmw *TeaboxArgsMainWindow) GetSecrets() []string {
+	secrets := []string{}
+	for _, arg := range tmw.argindex {
+		if val := tmw.argset[arg]; val != "" && tmw.isSecret(tmw.namedArg[arg]) {
+			secrets = append(secrets, val)
+		}
+	}
+
+	return secrets
+}
+
+// GetValues returns the current values of the fields by their labels. Toggles have their state as a bool.
+func (tmw *TeaboxArgsMainWindow) GetValues() map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, item := range tmw.GetFormItems() {
+		if value, ok := tmw.getFieldValue(item); ok {
+			values[item.GetLabel()] = value
+		}
+	}
+
+	return values
+}
+
+// Current value of a field, which is the value of its argument, or the state of a toggle.
+// Fields without an argument, like info texts, have no value. Secrets are never given away,
+// so only JOB_SECRET_MASK tells that they are set.
+func (tmw *TeaboxArgsMainWindow) getFieldValue(item crtview.FormItem) (interface{}, bool) {
+	arg := tmw.labeledArg[item.GetLabel()]
+	if arg == nil || arg.GetWidgetType() == "info" {
+		return nil, false
+	}
+	if field, ok := item.(*crtview.CheckBox); ok {
+		return field.IsChecked(), true
+	}
+
+	val := tmw.argset[arg.GetArgName()]
+	if val != "" && tmw.isSecret(arg) {
+		val = teaboxlib.JOB_SECRET_MASK
+	}
+
+	return val, true
+}
+
+// Argument is a secret, which value is never shown or stored
+func (tmw *TeaboxArgsMainWindow) isSecret(arg *teaboxlib.TeaConfModArg) bool {
+	return arg.GetWidgetType() == "password" || arg.GetWidgetType() == "masked"
 }
 
 // AddArgWidgets adds actual widgets for each argument
-func (tmw *TeaboxArgsMainWindow) AddArgWidgets(cmd *teaboxlib.TeaConfModCommand) {
+func (tmw *TeaboxArgsMainWindow) AddArgWidgets(cmd *teaboxlib.TeaConfModCommand) error {
 	tmw.confModCommand = cmd
 	for _, a := range tmw.confModCommand.GetArguments() {
 		tmw.namedArg[a.GetArgName()] = a
@@ -218,10 +307,11 @@ func (tmw *TeaboxArgsMainWindow) AddArgWidgets(cmd *teaboxlib.TeaConfModCommand)
 		case "info":
 			tmw.AddInfoTextField(cmd.GetCommandPath(), a)
 		default:
-			fmt.Printf("Module config error: Unknown widget definition \"%s\" for command argument \"%s\" at %s\n", a.GetWidgetType(), cmd.GetTitle(), cmd.GetCommandPath())
-			os.Exit(1)
+			return fmt.Errorf("unknown widget definition \"%s\" for command argument \"%s\" at %s", a.GetWidgetType(), cmd.GetTitle(), cmd.GetCommandPath())
 		}
 	}
+
+	return nil
 }
 
 func (tmw *TeaboxArgsMainWindow) AddInfoTextField(cmdpath string, arg *teaboxlib.TeaConfModArg) error {
@@ -279,11 +369,14 @@ func (tmw *TeaboxArgsMainWindow) AddTabularField(arg *teaboxlib.TeaConfModArg) e
 		tmw.AddArgument(tmw.GetId(), arg.GetArgName(), tabular.GetValueAt(row-1))
 	})
 	tabular.SetMarkerIcon(teaboxlib.LABEL_TABULAR_SELECTED)
-	tabular.Select(1, 1)
 	tmw.Form.AddFormItem(tabular)
 
-	// Pre-select first value
-	tmw.AddArgument(tmw.GetId(), arg.GetArgName(), tabular.GetValueAt(0))
+	// Pre-select first value, if there is any. Rows may be added later over the API.
+	tmw.tabularRows[arg.GetWidgetLabel()] = len(rows)
+	if len(rows) > 0 {
+		tabular.Select(1, 1)
+		tmw.AddArgument(tmw.GetId(), arg.GetArgName(), tabular.GetValueAt(0))
+	}
 
 	return nil
 }
@@ -361,7 +454,7 @@ func (tmw *TeaboxArgsMainWindow) AddCheckBox(arg *teaboxlib.TeaConfModArg) error
 	}
 
 	tmw.Form.AddCheckBox(arg.GetWidgetLabel(), "", state, func(checked bool) {
-		sig := teaboxlib.NewSigCall(tmw.confModCommand)
+		sig := teaboxlib.NewSigCall(tmw.confModCommand).SetEnv(tmw.env)
 		if checked {
 			// Add argument notification
 			tmw.AddArgument(tmw.GetId(), arg.GetArgName(), arg.GetOptions()[0].GetLabel())
@@ -384,6 +477,12 @@ func (tmw *TeaboxArgsMainWindow) GetSocketAcceptAction() func(*teaboxlib.TeaboxA
 	return func(call *teaboxlib.TeaboxAPICall) string {
 		switch call.GetClass() {
 
+		// Reading the current values, nothing to redraw
+		case teaboxlib.FORM_GET_BY_LABEL:
+			return tmw.getField(call, tmw.GetFormItemByLabel(call.GetKey()))
+		case teaboxlib.FORM_GET_BY_ORD:
+			return tmw.getField(call, tmw.GetFormItem(call.GetKeyAsInt()))
+
 		// Overwriting with the new values
 		case teaboxlib.FORM_SET_BY_LABEL:
 			tmw.updateField(call, tmw.GetFormItemByLabel(call.GetKey()), __OP_W_SET)
@@ -398,7 +497,7 @@ func (tmw *TeaboxArgsMainWindow) GetSocketAcceptAction() func(*teaboxlib.TeaboxA
 		case teaboxlib.FORM_ADD_BY_ORD:
 			tmw.updateField(call, tmw.GetFormItem(call.GetKeyAsInt()), __OP_W_ADD)
 		case teaboxlib.FORM_ADD_TABLE_BY_ORD:
-			tmw.updateField(call, tmw.GetFormItem(call.GetKeyAsInt()), __OP_W_SET)
+			tmw.updateField(call, tmw.GetFormItem(call.GetKeyAsInt()), __OP_W_ADD)
 
 		// Clearing/resetting
 		case teaboxlib.FORM_CLR_BY_LABEL:
@@ -406,13 +505,191 @@ func (tmw *TeaboxArgsMainWindow) GetSocketAcceptAction() func(*teaboxlib.TeaboxA
 		case teaboxlib.FORM_CLR_BY_ORD:
 			tmw.updateField(call, tmw.GetFormItem(call.GetKeyAsInt()), __OP_W_CLR)
 		case teaboxlib.FORM_CLR_TABLE_BY_ORD:
-			tmw.updateField(call, tmw.GetFormItem(call.GetKeyAsInt()), __OP_W_SET)
+			tmw.updateField(call, tmw.GetFormItem(call.GetKeyAsInt()), __OP_W_CLR)
+
+		// Hiding/showing
+		case teaboxlib.FORM_HIDE_BY_LABEL:
+			tmw.setFieldVisible(call, tmw.GetFormItemByLabel(call.GetKey()), false)
+		case teaboxlib.FORM_HIDE_BY_ORD:
+			tmw.setFieldVisible(call, tmw.GetFormItem(call.GetKeyAsInt()), false)
+		case teaboxlib.FORM_SHOW_BY_LABEL:
+			tmw.setFieldVisible(call, tmw.GetFormItemByLabel(call.GetKey()), true)
+		case teaboxlib.FORM_SHOW_BY_ORD:
+			tmw.setFieldVisible(call, tmw.GetFormItem(call.GetKeyAsInt()), true)
+
+		// Disabling/enabling
+		case teaboxlib.FORM_DISABLE_BY_LABEL:
+			tmw.setFieldEnabled(call, tmw.GetFormItemByLabel(call.GetKey()), false)
+		case teaboxlib.FORM_DISABLE_BY_ORD:
+			tmw.setFieldEnabled(call, tmw.GetFormItem(call.GetKeyAsInt()), false)
+		case teaboxlib.FORM_ENABLE_BY_LABEL:
+			tmw.setFieldEnabled(call, tmw.GetFormItemByLabel(call.GetKey()), true)
+		case teaboxlib.FORM_ENABLE_BY_ORD:
+			tmw.setFieldEnabled(call, tmw.GetFormItem(call.GetKeyAsInt()), true)
 		}
+
+		// The form can be already shown, not only being loaded
+		if call.IsHandled() {
+			teabox.GetTeaboxApp().Draw()
+		}
+		return ""
+	}
+}
+
+func (tmw *TeaboxArgsMainWindow) getField(call *teaboxlib.TeaboxAPICall, item crtview.FormItem) string {
+	if item == nil {
+		call.SetNotFound(fmt.Errorf("no such field \"%s\"", call.GetKey()))
+		return ""
+	}
+
+	value, ok := tmw.getFieldValue(item)
+	if !ok {
+		call.SetError(fmt.Errorf("field \"%s\" has no value", call.GetKey()))
 		return ""
 	}
+
+	call.SetHandled() // Value can be empty
+	return fmt.Sprintf("%v", value)
+}
+
+func (tmw *TeaboxArgsMainWindow) setFieldVisible(call *teaboxlib.TeaboxAPICall, item crtview.FormItem, visible bool) {
+	if item == nil {
+		call.SetNotFound(fmt.Errorf("no such field \"%s\"", call.GetKey()))
+		return
+	}
+	box, ok := item.(interface {
+		Show() *crtview.Box
+		Hide() *crtview.Box
+	})
+	if !ok {
+		call.SetError(fmt.Errorf("field \"%s\" can not be hidden", call.GetKey()))
+		return
+	}
+
+	if visible {
+		box.Show()
+		delete(tmw.hidden, item.GetLabel())
+	} else {
+		focused := item.GetFocusable().HasFocus()
+		box.Hide()
+		tmw.hidden[item.GetLabel()] = true
+
+		// Keys would still go to the hidden field
+		if focused {
+			tmw.SetFocus(tmw.nextVisibleItem(tmw.IndexOfFormItem(item)))
+			teabox.GetTeaboxApp().SetFocus(tmw)
+		}
+	}
+
+	call.SetHandled()
+}
+
+func (tmw *TeaboxArgsMainWindow) setFieldEnabled(call *teaboxlib.TeaboxAPICall, item crtview.FormItem, enabled bool) {
+	if item == nil {
+		call.SetNotFound(fmt.Errorf("no such field \"%s\"", call.GetKey()))
+		return
+	}
+	box, ok := item.(interface {
+		SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey) *crtview.Box
+		SetMouseCapture(func(action crtview.MouseAction, event *tcell.EventMouse) (crtview.MouseAction, *tcell.EventMouse)) *crtview.Box
+	})
+	if !ok {
+		call.SetError(fmt.Errorf("field \"%s\" can not be disabled", call.GetKey()))
+		return
+	}
+
+	if enabled {
+		box.SetInputCapture(nil)
+		box.SetMouseCapture(nil)
+		delete(tmw.disabled, item.GetLabel())
+	} else {
+		box.SetInputCapture(disabledKeyCapture)
+		box.SetMouseCapture(func(action crtview.MouseAction, event *tcell.EventMouse) (crtview.MouseAction, *tcell.EventMouse) {
+			return action, nil
+		})
+		tmw.disabled[item.GetLabel()] = true
+	}
+
+	call.SetHandled()
+}
+
+// A disabled field only lets the focus go further. Enter does the same as Tab, so it never changes anything.
+func disabledKeyCapture(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyTab, tcell.KeyBacktab, tcell.KeyEscape:
+		return event
+	case tcell.KeyEnter:
+		return tcell.NewEventKey(tcell.KeyTab, 0, tcell.ModNone)
+	}
+	return nil
+}
+
+// Index of the next visible field after the given one, or of the first button, if there is none.
+// Returns -1, if there is nothing to focus at all.
+func (tmw *TeaboxArgsMainWindow) nextVisibleItem(idx int) int {
+	count := tmw.GetFormItemCount()
+	for i := idx + 1; i < count; i++ {
+		if tmw.GetFormItem(i).IsVisible() {
+			return i
+		}
+	}
+	if tmw.GetButtonCount() > 0 {
+		return count // Buttons are counted after the fields
+	}
+	for i := 0; i < idx; i++ {
+		if tmw.GetFormItem(i).IsVisible() {
+			return i
+		}
+	}
+	return -1
+}
+
+// Focus is called, when the form receives the focus. It never goes to a hidden field.
+func (tmw *TeaboxArgsMainWindow) Focus(delegate func(p crtview.Primitive)) {
+	tmw.Form.Focus(func(p crtview.Primitive) {
+		if item, ok := p.(crtview.FormItem); ok && !item.IsVisible() {
+			if next := tmw.nextVisibleItem(tmw.IndexOfFormItem(item)); next >= 0 {
+				tmw.SetFocus(next)
+				tmw.Form.Focus(delegate)
+				return
+			}
+		}
+		delegate(p)
+	})
+}
+
+// Draw the form, dimming the disabled fields
+func (tmw *TeaboxArgsMainWindow) Draw(screen tcell.Screen) {
+	tmw.Form.Draw(screen)
+	if len(tmw.disabled) == 0 || !tmw.IsVisible() {
+		return
+	}
+
+	fx, fy, fwidth, fheight := tmw.GetInnerRect()
+	for _, item := range tmw.GetFormItems() {
+		if !tmw.disabled[item.GetLabel()] || !item.IsVisible() {
+			continue
+		}
+
+		x, y, width, height := item.GetRect()
+		for row := y; row < y+height; row++ {
+			for col := x; col < x+width; col++ {
+				if row < fy || row >= fy+fheight || col < fx || col >= fx+fwidth {
+					continue // Scrolled out of the form
+				}
+				mainc, combc, style, _ := screen.GetContent(col, row)
+				screen.SetContent(col, row, mainc, combc, style.Foreground(teaboxlib.FORM_FIELD_TEXT_DISABLED))
+			}
+		}
+	}
 }
 
 func (tmw *TeaboxArgsMainWindow) updateField(call *teaboxlib.TeaboxAPICall, item crtview.FormItem, op int) {
+	if item == nil {
+		// Every form of the module gets the call, but the field is on one of them
+		call.SetNotFound(fmt.Errorf("no such field \"%s\"", call.GetKey()))
+		return
+	}
 	arg := tmw.labeledArg[item.GetLabel()]
 
 	// Reset all supported fields
@@ -465,45 +742,42 @@ func (tmw *TeaboxArgsMainWindow) updateField(call *teaboxlib.TeaboxAPICall, item
 		}
 
 	case *crtforms.FormTabularChoice:
-		if call.GetType() != "json" {
-			teabox.GetTeaboxApp().Stop(fmt.Sprintf("table data requires two dimentional array (tabular)"+
-				" in JSON format. Current type: %s", call.GetType()))
-		}
-
-		td := call.GetValue()
-		if td == nil {
-			teabox.GetTeaboxApp().Stop("tabular field requested for the update, but no JSON data was found")
-		}
-
-		var rdata []interface{}
-		if err := json.Unmarshal([]byte(td.(string)), &rdata); err != nil {
-			teabox.GetTeaboxApp().Stop(fmt.Sprintf("unable to parse tabular data: %s. Is the whole JSON payload is in one "+
-				"single-quoted string and scalar values are quoted with a double-quotes?", err.Error()))
-		}
+		var rows [][]string
+		if op != __OP_W_CLR {
+			if call.GetType() != "json" {
+				call.SetError(fmt.Errorf("table data requires two dimentional array (tabular)"+
+					" in JSON format. Current type: %s", call.GetType()))
+				return
+			}
 
-		rows := [][]string{}
-		for _, iRow := range rdata {
-			row := []string{}
-			for _, c := range iRow.([]interface{}) {
-				row = append(row, c.(string))
+			if err := json.Unmarshal([]byte(call.GetValue().(string)), &rows); err != nil {
+				call.SetError(fmt.Errorf("unable to parse tabular data: %s. Is the whole JSON payload is in one "+
+					"single-quoted string and scalar values are quoted with a double-quotes?", err.Error()))
+				return
 			}
-			rows = append(rows, row)
 		}
 
 		switch op {
 		case __OP_W_ADD:
 			field.AppendContent(rows)
+			tmw.tabularRows[item.GetLabel()] += len(rows)
 		case __OP_W_CLR:
-			field.Clear()
+			// Header stays, only the rows are gone
+			field.ReplaceContent([][]string{})
+			tmw.tabularRows[item.GetLabel()] = 0
 		case __OP_W_SET:
 			field.ReplaceContent(rows)
+			tmw.tabularRows[item.GetLabel()] = len(rows)
 		}
 
-		if op != __OP_W_CLR {
+		// Empty table has nothing to select
+		if tmw.tabularRows[item.GetLabel()] > 0 {
 			field.Select(1, 1)
 			tmw.AddArgument(tmw.GetId(), arg.GetArgName(), field.GetValueAt(0))
 		} else {
 			tmw.RemoveArgument(tmw.GetId(), arg.GetArgName())
 		}
 	}
+
+	call.SetHandled()
 }
@@ -0,0 +1,128 @@
+package teawidgets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/isbm/teabox/teaboxlib"
+	"gitlab.com/isbm/teabox/teaboxlib/teaboxclient"
+)
+
+type TeaboxArgsMainWindowTestSuite struct {
+	suite.Suite
+	dir    string
+	form   *TeaboxArgsMainWindow
+	server *teaboxlib.TeaboxSocketServer
+	client *teaboxclient.TeaboxClient
+}
+
+func TestTeaboxArgsMainWindowTestSuite(t *testing.T) {
+	suite.Run(t, new(TeaboxArgsMainWindowTestSuite))
+}
+
+// Calls are sent over the socket to the real action of a form with two tables:
+// "Packages" with a row, and "Extras" without any, and an empty "Password".
+func (s *TeaboxArgsMainWindowTestSuite) SetupTest() {
+	cmd, err := teaboxlib.NewTeaConfModCommand(map[interface{}]interface{}{
+		"path":  "/bin/true",
+		"title": "Install",
+		"args": []interface{}{
+			map[interface{}]interface{}{
+				"type":       "tabular",
+				"name":       "--pkg",
+				"label":      "Packages",
+				"options":    []interface{}{[]interface{}{"Name", "Version"}, []interface{}{"vim", "9.0"}},
+				"attributes": []interface{}{"value = 1"},
+			},
+			map[interface{}]interface{}{
+				"type":    "tabular",
+				"name":    "--extra",
+				"label":   "Extras",
+				"options": []interface{}{[]interface{}{"Name"}},
+			},
+			map[interface{}]interface{}{
+				"type":    "password",
+				"name":    "--password",
+				"label":   "Password",
+				"options": []interface{}{[]interface{}{}},
+			},
+		},
+	})
+	s.Require().NoError(err)
+
+	s.form = NewTeaboxArgsMainWindow("Test", "Install", 0)
+	s.Require().NoError(s.form.AddArgWidgets(cmd))
+
+	s.dir, err = os.MkdirTemp("", "teabox-form-")
+	s.Require().NoError(err)
+	sock := filepath.Join(s.dir, "teabox.sock")
+
+	s.server = teaboxlib.NewTeaboxSocketServer().AddLocalAction(s.form.GetSocketAcceptAction())
+	s.Require().NoError(s.server.Start(sock))
+	s.client = teaboxclient.NewTeaboxClientAt(sock, "")
+}
+
+func (s *TeaboxArgsMainWindowTestSuite) TearDownTest() {
+	s.NoError(s.client.Close())
+	s.NoError(s.server.Stop())
+	os.RemoveAll(s.dir)
+}
+
+// Table without rows has no value and nothing to select
+func (s *TeaboxArgsMainWindowTestSuite) TestEmptyTable() {
+	value, err := s.client.GetField("Extras")
+	s.NoError(err)
+	s.Equal("", value)
+
+	s.NoError(s.client.AddTable(1, [][]string{{"nano"}}))
+	value, err = s.client.GetField("Extras")
+	s.NoError(err)
+	s.Equal("nano", value)
+}
+
+// Clearing or emptying a table removes its value
+func (s *TeaboxArgsMainWindowTestSuite) TestClearTable() {
+	value, err := s.client.GetField("Packages")
+	s.NoError(err)
+	s.Equal("vim", value)
+
+	s.NoError(s.client.ClearTable(0))
+	value, err = s.client.GetField("Packages")
+	s.NoError(err)
+	s.Equal("", value)
+
+	s.NoError(s.client.SetTable(0, [][]string{{"emacs", "29"}}))
+	value, err = s.client.GetField("Packages")
+	s.NoError(err)
+	s.Equal("emacs", value)
+
+	s.NoError(s.client.SetTable(0, [][]string{}))
+	value, err = s.client.GetField("Packages")
+	s.NoError(err)
+	s.Equal("", value)
+}
+
+// Rows are added to the bottom, the first one stays selected
+func (s *TeaboxArgsMainWindowTestSuite) TestAddTable() {
+	s.NoError(s.client.AddTable(0, [][]string{{"emacs", "29"}}))
+	value, err := s.client.GetField("Packages")
+	s.NoError(err)
+	s.Equal("vim", value)
+}
+
+// Secret is masked, but the command still gets it
+func (s *TeaboxArgsMainWindowTestSuite) TestSecretField() {
+	value, err := s.client.GetField("Password")
+	s.NoError(err)
+	s.Equal("", value)
+
+	s.NoError(s.client.SetField("Password", "hunter2"))
+	value, err = s.client.GetField("Password")
+	s.NoError(err)
+	s.Equal(teaboxlib.JOB_SECRET_MASK, value)
+
+	s.Equal(teaboxlib.JOB_SECRET_MASK, s.form.GetValues()["Password"])
+	s.Contains(s.form.GetCommandArguments(""), "--password=hunter2")
+}
@@ -0,0 +1,114 @@
+package teawidgets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"gitlab.com/isbm/teabox"
+	"gitlab.com/isbm/teabox/teaboxlib"
+	"golang.org/x/sys/unix"
+)
+
+/*
+RunSuspended suspends the UI, restoring the terminal, and runs the command on it, so editors,
+pagers, "passwd" and alike are working as usual. The UI is resumed once the command exits.
+If timeout is not zero, the command is terminated after it.
+
+The command is run from the event loop, so this must not be called from the event loop itself,
+e.g. from a key or selection handler, otherwise it blocks forever.
+*/
+func RunSuspended(cmd *exec.Cmd, timeout time.Duration) error {
+	var err error
+	var timedOut bool
+	var mtx sync.Mutex
+
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	done := make(chan struct{})
+	teabox.GetTeaboxApp().QueueUpdate(func() {
+		defer close(done)
+
+		suspended := teabox.GetTeaboxApp().GetScreen()
+		resumed := teabox.GetTeaboxApp().Suspend(func() {
+			// Command gets the terminal for its own process group, just like a shell does it for a job.
+			// So Ctrl+C is sent only to the command, and the shells with job control are working too.
+			foreground, fgErr := unix.IoctlGetInt(0, unix.TIOCGPGRP)
+			if fgErr == nil {
+				cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Foreground: true, Ctty: 0}
+				defer func() {
+					// Taking the terminal back from the background would stop the whole Teabox otherwise
+					signal.Ignore(syscall.SIGTTOU)
+					_ = unix.IoctlSetPointerInt(0, unix.TIOCSPGRP, foreground)
+					signal.Reset(syscall.SIGTTOU)
+				}()
+			}
+
+			if err = cmd.Start(); err != nil {
+				return
+			}
+
+			if timeout > 0 {
+				timer := time.AfterFunc(timeout, func() {
+					mtx.Lock()
+					timedOut = true
+					mtx.Unlock()
+
+					_ = cmd.Process.Signal(syscall.SIGTERM)
+					time.AfterFunc(teaboxlib.CANCEL_GRACE_PERIOD, func() {
+						_ = cmd.Process.Kill() // Does nothing, if the command has already quit
+					})
+				})
+				defer timer.Stop()
+			}
+
+			err = cmd.Wait()
+		})
+
+		// The new screen is set by the event polling in the background after the resume. Drawing
+		// on the finalised old screen never returns, so the event loop continues only after that.
+		for resumed && teabox.GetTeaboxApp().GetScreen() == suspended {
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+	<-done
+
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	if timedOut {
+		return fmt.Errorf("%w after %s", ErrLanderTimeout, timeout)
+	}
+
+	return err
+}
+
+// RunInteractive runs the module command on the real terminal, suspending the UI for that time.
+// The environment of the run is added to the command. Exit code of the command is returned
+// as LanderExitError, same way as the landing windows do.
+func RunInteractive(modcmd *teaboxlib.TeaConfModCommand, env []string, cmdargs ...string) error {
+	cmdpath := modcmd.GetCommandPath()
+	cmd := modcmd.NewCommand(cmdpath, cmdargs...)
+	cmd.Env = append(cmd.Env, env...)
+
+	err := RunSuspended(cmd, modcmd.GetTimeout())
+	if err == nil || isLanderStop(err) {
+		return err
+	}
+
+	teabox.AddToFile(teaboxlib.LOG_FILENAME,
+		fmt.Sprintf("Error: command \"%s %s\" quit as %s", cmdpath, strings.Join(cmdargs, " "), err.Error()))
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &LanderExitError{code: exitErr.ExitCode(), message: fmt.Sprintf("Command has quit with the exit code %d", exitErr.ExitCode())}
+	}
+
+	return fmt.Errorf("Error: command \"%s %s\" quit as %s", cmdpath, strings.Join(cmdargs, " "), err.Error())
+}
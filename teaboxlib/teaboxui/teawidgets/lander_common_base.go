@@ -1,16 +1,226 @@
 package teawidgets
 
-import "gitlab.com/isbm/teabox"
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/isbm/crtview"
+	"gitlab.com/isbm/teabox/teaboxlib"
+)
 
 // Common methods mixin
 type teaCommonBaseWindowLander struct {
+	stepsIdx   []int
+	stepsTitle map[int]string
+	stepsState map[int]int
+	env        []string // Environment of the run, added to every command
+
+	isForeground   func() bool // Tells if the run is shown to the user
+	waitForeground func() bool // Waits until the run is shown, false if it was cancelled meanwhile
+
+	cmd       *exec.Cmd // Currently running command
+	timeout   time.Duration
+	cancelled bool
+	timedOut  bool
+	cmdMtx    sync.Mutex
+
+	pty         *os.File // Pseudo-terminal of the running command, if any
+	term        *TeaTerminalWriter
+	ptyMtx      sync.Mutex
+	interactive bool // User types into the pseudo-terminal, so the cursor is shown
+}
+
+func newTeaCommonBaseWindowLander() *teaCommonBaseWindowLander {
+	return (&teaCommonBaseWindowLander{
+		isForeground:   func() bool { return true },
+		waitForeground: func() bool { return true },
+	}).resetSteps()
+}
+
+// SetEnv sets the environment of the run, such as its socket, which is added to every command
+func (base *teaCommonBaseWindowLander) SetEnv(env []string) {
+	base.env = env
 }
 
-// StopListener
-func (base *teaCommonBaseWindowLander) StopListener() error {
-	// Stop Unix socket
-	if teabox.GetTeaboxApp().GetCallbackServer().IsRunning() {
-		return teabox.GetTeaboxApp().GetCallbackServer().Stop()
+// SetForegroundActions sets the functions, which tell if the run is shown to the user, and wait until it is
+func (base *teaCommonBaseWindowLander) SetForegroundActions(isForeground, waitForeground func() bool) {
+	base.isForeground = isForeground
+	base.waitForeground = waitForeground
+}
+
+// Create a command of the module with the environment of the run
+func (base *teaCommonBaseWindowLander) command(modcmd *teaboxlib.TeaConfModCommand, cmdpath string, cmdargs ...string) *exec.Cmd {
+	cmd := modcmd.NewCommand(cmdpath, cmdargs...)
+	cmd.Env = append(cmd.Env, base.env...)
+
+	return cmd
+}
+
+// Start a command in its own process group, so it can be cancelled together with all its children.
+// If timeout is not zero, the command is terminated after it.
+func (base *teaCommonBaseWindowLander) startCommand(cmd *exec.Cmd, timeout time.Duration) error {
+	base.cmdMtx.Lock()
+	defer base.cmdMtx.Unlock()
+
+	// Commands in a pseudo-terminal are already leaders of their own session and process group
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	}
+	base.cancelled = false
+	base.timedOut = false
+	base.timeout = timeout
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	base.cmd = cmd
+
+	if timeout > 0 {
+		time.AfterFunc(timeout, func() {
+			base.cmdMtx.Lock()
+			defer base.cmdMtx.Unlock()
+
+			if base.cmd == cmd && !base.cancelled {
+				base.timedOut = true
+				base.terminate()
+			}
+		})
 	}
+
 	return nil
 }
+
+// Wait for the command, started by startCommand. If the command was cancelled or timed out,
+// ErrLanderCancelled or ErrLanderTimeout is returned instead of its own exit error.
+func (base *teaCommonBaseWindowLander) waitCommand(cmd *exec.Cmd) error {
+	err := cmd.Wait()
+
+	base.cmdMtx.Lock()
+	defer base.cmdMtx.Unlock()
+
+	base.cmd = nil
+	if base.cancelled {
+		return ErrLanderCancelled
+	} else if base.timedOut {
+		return fmt.Errorf("%w after %s", ErrLanderTimeout, base.timeout)
+	}
+
+	return err
+}
+
+// Turn the error of a finished command into LanderExitError with the given message, keeping its exit code.
+func (base *teaCommonBaseWindowLander) exitError(err error, message string) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &LanderExitError{code: exitErr.ExitCode(), message: message}
+	}
+
+	return errors.New(message)
+}
+
+// Send SIGTERM to the process group of the running command, and if it is still
+// running after the grace period, kill it. Lock must be already acquired.
+func (base *teaCommonBaseWindowLander) terminate() {
+	cmd := base.cmd
+	terminateGroup(cmd.Process.Pid, func() bool {
+		base.cmdMtx.Lock()
+		defer base.cmdMtx.Unlock()
+
+		return base.cmd == cmd
+	})
+}
+
+// Send SIGTERM to the process group, and if it is still running after the grace period, kill it.
+// The group is not touched anymore, once its leader is waited for, as its ID can be taken again.
+func terminateGroup(pgid int, running func() bool) {
+	_ = syscall.Kill(-pgid, syscall.SIGTERM)
+
+	time.AfterFunc(teaboxlib.CANCEL_GRACE_PERIOD, func() {
+		if running() {
+			_ = syscall.Kill(-pgid, syscall.SIGKILL)
+		}
+	})
+}
+
+// Cancel the running command. Its process group gets SIGTERM, and if it is still
+// running after the grace period, it is killed.
+func (base *teaCommonBaseWindowLander) Cancel() {
+	base.cmdMtx.Lock()
+	defer base.cmdMtx.Unlock()
+
+	if base.cmd == nil || base.cancelled || base.timedOut {
+		return
+	}
+
+	base.cancelled = true
+	base.terminate()
+}
+
+// Input capture of a lander, which cancels the running command on Esc key
+func (base *teaCommonBaseWindowLander) cancelKeyCapture(event *tcell.EventKey) *tcell.EventKey {
+	if event.Key() == tcell.KeyEscape {
+		base.cmdMtx.Lock()
+		running := base.cmd != nil
+		base.cmdMtx.Unlock()
+
+		if running {
+			base.Cancel()
+			return nil
+		}
+	}
+
+	return event
+}
+
+// Forget all the steps of a chain
+func (base *teaCommonBaseWindowLander) resetSteps() *teaCommonBaseWindowLander {
+	base.stepsIdx = []int{}
+	base.stepsTitle = map[int]string{}
+	base.stepsState = map[int]int{}
+
+	return base
+}
+
+// Register a step of a chain or update its state
+func (base *teaCommonBaseWindowLander) setStep(idx int, title string, state int) {
+	if _, exists := base.stepsState[idx]; !exists {
+		base.stepsIdx = append(base.stepsIdx, idx)
+	}
+	base.stepsTitle[idx] = title
+	base.stepsState[idx] = state
+}
+
+// Format a single step with its state mark, using dynamic colors
+func (base *teaCommonBaseWindowLander) formatStep(idx int) string {
+	var mark string
+	switch base.stepsState[idx] {
+	case STEP_RUNNING:
+		mark = "[yellow]▶ running[-]"
+	case STEP_DONE:
+		mark = "[green]✔ done[-]"
+	case STEP_SKIPPED:
+		mark = "[gray]┄ skipped[-]"
+	case STEP_FAILED:
+		mark = "[red]✘ failed[-]"
+	case STEP_CANCELLED:
+		mark = "[red]■ cancelled[-]"
+	}
+
+	return fmt.Sprintf("%s: %s", crtview.Escape(base.stepsTitle[idx]), mark)
+}
+
+// Get a report of all steps of a chain, one per line
+func (base *teaCommonBaseWindowLander) getStepsReport() string {
+	out := []string{}
+	for _, idx := range base.stepsIdx {
+		out = append(out, base.formatStep(idx))
+	}
+
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,392 @@
+package teawidgets
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/isbm/crtview"
+	"github.com/isbm/crtview/crtwin/crtforms"
+	"gitlab.com/isbm/teabox"
+	"gitlab.com/isbm/teabox/teaboxlib"
+)
+
+/*
+TeaListWindowLander is a lander page with a list of results. Rows are either streamed
+over the Unix socket or printed to the STDOUT by the module, one row per line with
+the columns separated by a TAB. Once the module is finished, user can scroll and filter
+the list and select one row of it. The selected value is stored in the session.
+*/
+
+type TeaListWindowLander struct {
+	modId           string
+	header          []string
+	rows            [][]string
+	visible         [][]string // Rows, matching the filter
+	valueColumn     int
+	selected        chan string // Value, selected by the user. Sent only while selecting.
+	selecting       bool
+	selectCancelled bool // Run was cancelled, so there is nothing to select
+	mtx             sync.Mutex
+
+	action    func(call *teaboxlib.TeaboxAPICall) string
+	titleBar  *crtview.TextView
+	statusBar *crtview.TextView
+	filter    *crtview.InputField
+	tableBox  *crtview.Flex
+	table     *crtforms.FormTabularChoice
+
+	*teaCommonBaseWindowLander
+	*crtview.Flex
+}
+
+// NewTeaListWindowLander constructor. The "modId" is a session namespace of the module.
+func NewTeaListWindowLander(modId string) *TeaListWindowLander {
+	return (&TeaListWindowLander{
+		Flex:                      crtview.NewFlex(),
+		teaCommonBaseWindowLander: newTeaCommonBaseWindowLander(),
+		modId:                     modId,
+		titleBar:                  crtview.NewTextView(),
+		statusBar:                 crtview.NewTextView(),
+		filter:                    crtview.NewInputField(),
+		tableBox:                  crtview.NewFlex(),
+		selected:                  make(chan string, 1),
+	}).init()
+}
+
+func (ll *TeaListWindowLander) init() *TeaListWindowLander {
+	ll.SetDirection(crtview.FlexRow)
+
+	// Top label
+	ll.titleBar.SetBackgroundColor(tcell.NewRGBColor(0x88, 0x88, 0x88))
+	ll.titleBar.SetTextColor(tcell.ColorBlack)
+	ll.AddItem(ll.titleBar, 1, 0, false)
+
+	// Filter of the rows
+	ll.filter.SetLabel(" Filter: ")
+	ll.filter.SetBackgroundColor(teaboxlib.WORKSPACE_BACKGROUND)
+	ll.filter.SetLabelColor(teaboxlib.WORKSPACE_HEADER_TEXT)
+	ll.filter.SetFieldBackgroundColor(teaboxlib.FORM_FIELD_BACKGROUND)
+	ll.filter.SetFieldBackgroundColorFocused(teaboxlib.FORM_FIELD_BACKGROUND_FOCUSED)
+	ll.filter.SetChangedFunc(func(text string) {
+		ll.applyFilter()
+	})
+	ll.filter.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEscape:
+			ll.finish("")
+		default:
+			teabox.GetTeaboxApp().SetFocus(ll.table)
+		}
+	})
+	ll.AddItem(ll.filter, 1, 0, false)
+
+	// Table itself
+	ll.tableBox.SetBackgroundColor(teaboxlib.WORKSPACE_BACKGROUND)
+	ll.newTable()
+	ll.AddItem(ll.tableBox, 0, 1, true)
+
+	// Bottom status
+	ll.statusBar.SetBackgroundColor(tcell.ColorDarkGrey)
+	ll.statusBar.SetTextColor(tcell.ColorBlack)
+	ll.statusBar.SetDynamicColors(true)
+	ll.AddItem(ll.statusBar, 1, 0, false)
+
+	// Define API receiver
+	ll.action = func(call *teaboxlib.TeaboxAPICall) string {
+		switch call.GetClass() {
+		case teaboxlib.LIST_TITLE:
+			ll.titleBar.SetText(call.GetString())
+		case teaboxlib.LIST_STATUS:
+			ll.statusBar.SetText(call.GetString())
+		case teaboxlib.LIST_HEADER:
+			var header []string
+			if err := ll.unmarshal(call, &header); err != nil {
+				call.SetError(err)
+				return ""
+			}
+			ll.SetHeader(header)
+		case teaboxlib.LIST_ADD:
+			var rows [][]string
+			if err := ll.unmarshal(call, &rows); err != nil {
+				call.SetError(err)
+				return ""
+			}
+			ll.AddRows(rows...)
+		case teaboxlib.LIST_CLEAR:
+			ll.ClearRows()
+		case teaboxlib.LIST_VALUE:
+			ll.valueColumn = call.GetInt()
+		default:
+			return ""
+		}
+		call.SetHandled()
+		teabox.GetTeaboxApp().Draw()
+		return ""
+	}
+
+	ll.Reset()
+
+	return ll
+}
+
+// Unmarshal JSON payload of the API call
+func (ll *TeaListWindowLander) unmarshal(call *teaboxlib.TeaboxAPICall, v interface{}) error {
+	data, ok := call.GetValue().(string)
+	if call.GetType() != "json" || !ok {
+		return fmt.Errorf("%s requires data in JSON format", call.GetClass())
+	}
+
+	if err := json.Unmarshal([]byte(data), v); err != nil {
+		return fmt.Errorf("unable to parse %s data: %s", call.GetClass(), err.Error())
+	}
+
+	return nil
+}
+
+// Re-create the table widget. This happens every time the header is changed,
+// as the tabular choice has its header set only once.
+func (ll *TeaListWindowLander) newTable() {
+	ll.table = crtforms.NewFormTabularChoice("", ll.header, [][]string{}, false)
+	ll.table.SetBorder(false)
+	ll.table.SetBackgroundColor(teaboxlib.WORKSPACE_BACKGROUND)
+	ll.table.SetSelectedFunc(func(row, column int) {
+		ll.mtx.Lock()
+		var value string
+		if row > 0 && row <= len(ll.visible) {
+			value = ll.getValue(ll.visible[row-1])
+		}
+		ll.mtx.Unlock()
+
+		if value != "" {
+			ll.finish(value)
+		}
+	})
+	ll.table.SetFinishedFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEscape:
+			ll.finish("")
+		case tcell.KeyTab, tcell.KeyBacktab:
+			teabox.GetTeaboxApp().SetFocus(ll.filter)
+		}
+	})
+	ll.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// Keys are sent to the focused widget only, so the table cancels the run as well
+		if event = ll.cancelKeyCapture(event); event == nil {
+			return nil
+		}
+
+		if event.Rune() == '/' {
+			teabox.GetTeaboxApp().SetFocus(ll.filter)
+			return nil
+		}
+		return event
+	})
+
+	ll.tableBox.ClearItems()
+	ll.tableBox.AddItem(ll.table, 0, 1, true)
+}
+
+// Get a value of the row, according to the value column
+func (ll *TeaListWindowLander) getValue(row []string) string {
+	if ll.valueColumn > 0 && ll.valueColumn <= len(row) {
+		return row[ll.valueColumn-1]
+	}
+
+	return strings.Join(row, ",")
+}
+
+// Show only those rows, which are matching the filter in any column
+func (ll *TeaListWindowLander) applyFilter() {
+	ll.mtx.Lock()
+	defer ll.mtx.Unlock()
+
+	term := strings.ToLower(strings.TrimSpace(ll.filter.GetText()))
+	ll.visible = [][]string{}
+	for _, row := range ll.rows {
+		if term == "" || strings.Contains(strings.ToLower(strings.Join(row, "\t")), term) {
+			ll.visible = append(ll.visible, row)
+		}
+	}
+
+	ll.table.ReplaceContent(ll.visible)
+	if len(ll.visible) > 0 {
+		ll.table.Select(1, 0)
+	}
+}
+
+// Finish the selection. An empty value means nothing was selected.
+func (ll *TeaListWindowLander) finish(value string) {
+	ll.mtx.Lock()
+	defer ll.mtx.Unlock()
+
+	if !ll.selecting {
+		return
+	}
+
+	select {
+	case ll.selected <- value:
+	default:
+	}
+}
+
+// Start or stop waiting for the selection. Values, sent after it is stopped, are dropped.
+// Returns false, if the run is already cancelled.
+func (ll *TeaListWindowLander) setSelecting(selecting bool) bool {
+	ll.mtx.Lock()
+	defer ll.mtx.Unlock()
+
+	ll.selecting = selecting && !ll.selectCancelled
+	select {
+	case <-ll.selected:
+	default:
+	}
+
+	return !ll.selectCancelled
+}
+
+// Cancel the running command, or the selection, once the command is finished
+func (ll *TeaListWindowLander) Cancel() {
+	ll.mtx.Lock()
+	ll.selectCancelled = true
+	if ll.selecting {
+		select {
+		case ll.selected <- "":
+		default:
+		}
+	}
+	ll.mtx.Unlock()
+
+	ll.teaCommonBaseWindowLander.Cancel()
+}
+
+// SetHeader of the list. Header is also set with the "list.header" API call.
+func (ll *TeaListWindowLander) SetHeader(header []string) {
+	ll.header = header
+	ll.newTable()
+	ll.applyFilter()
+}
+
+// AddRows to the bottom of the list
+func (ll *TeaListWindowLander) AddRows(rows ...[]string) {
+	ll.mtx.Lock()
+	ll.rows = append(ll.rows, rows...)
+	ll.mtx.Unlock()
+
+	ll.applyFilter()
+}
+
+// ClearRows removes all rows from the list
+func (ll *TeaListWindowLander) ClearRows() {
+	ll.mtx.Lock()
+	ll.rows = [][]string{}
+	ll.mtx.Unlock()
+
+	ll.applyFilter()
+}
+
+// Reset all content to the initial values
+func (ll *TeaListWindowLander) Reset() {
+	ll.titleBar.SetText("")
+	ll.statusBar.SetText("")
+	ll.filter.SetText("")
+	ll.valueColumn = 0
+	ll.resetSteps()
+
+	ll.mtx.Lock()
+	ll.rows = [][]string{}
+	ll.mtx.Unlock()
+
+	ll.SetHeader([]string{})
+}
+
+// SetStepState of a command in a chain, displaying it on the status bar
+func (ll *TeaListWindowLander) SetStepState(idx int, title string, state int) {
+	ll.setStep(idx, title, state)
+	ll.statusBar.SetText(ll.formatStep(idx))
+}
+
+func (ll *TeaListWindowLander) AsWidgetPrimitive() crtview.Primitive {
+	var w TeaboxLandingWindow = ll
+	return w.(crtview.Primitive)
+}
+
+// Return window receiver action on Unix socket calls, specific per this widget.
+func (ll *TeaListWindowLander) GetWindowAction() func(call *teaboxlib.TeaboxAPICall) string {
+	return ll.action
+}
+
+// Action runs the command, collecting every line of its STDOUT as a row.
+// Once the command is finished, it waits for the user to select a row.
+func (ll *TeaListWindowLander) Action(modcmd *teaboxlib.TeaConfModCommand, cmdargs ...string) error {
+	ll.mtx.Lock()
+	ll.selectCancelled = false
+	ll.mtx.Unlock()
+
+	cmdpath := modcmd.GetCommandPath()
+	cmd := ll.command(modcmd, cmdpath, cmdargs...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("Error: cannot initialise communication for the %s: %s", cmdpath, err.Error())
+	}
+
+	buf := new(strings.Builder)
+	cmd.Stderr = buf
+
+	if err := ll.startCommand(cmd, modcmd.GetTimeout()); err != nil {
+		return fmt.Errorf("Error: command \"%s %s\" quit as %s", cmdpath, strings.Join(cmdargs, " "), err.Error())
+	}
+
+	// Streaming the STDOUT, each line is a row
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		if data := strings.TrimRight(scanner.Text(), "\r"); data != "" {
+			ll.AddRows(strings.Split(data, "\t"))
+			teabox.GetTeaboxApp().Draw()
+		}
+	}
+
+	if err := ll.waitCommand(cmd); isLanderStop(err) {
+		return err
+	} else if err != nil {
+		teabox.AddToFile(teaboxlib.LOG_FILENAME,
+			fmt.Sprintf("Error: command \"%s %s\" quit as %s\nOutput: %s",
+				cmdpath, strings.Join(cmdargs, " "), err.Error(), buf.String()))
+		return ll.exitError(err, buf.String())
+	}
+
+	ll.mtx.Lock()
+	empty := len(ll.rows) == 0
+	ll.mtx.Unlock()
+
+	if empty {
+		return nil
+	}
+
+	// Let the user pick a row, once the run is shown
+	if !ll.waitForeground() || !ll.setSelecting(true) {
+		return ErrLanderCancelled
+	}
+	defer ll.setSelecting(false)
+
+	ll.statusBar.SetText("Enter: select, /: filter, Esc: skip")
+	teabox.GetTeaboxApp().SetFocus(ll.table)
+	teabox.GetTeaboxApp().Draw()
+
+	value := <-ll.selected
+	ll.mtx.Lock()
+	cancelled := ll.selectCancelled
+	ll.mtx.Unlock()
+
+	if cancelled {
+		return ErrLanderCancelled
+	} else if value != "" {
+		teabox.GetTeaboxApp().GetSession().Set(ll.modId, "list.selected", value)
+		teabox.GetTeaboxApp().GetSession().Set(ll.modId, ":list.selected", value)
+	}
+
+	return nil
+}
@@ -2,8 +2,8 @@ package teawidgets
 
 import (
 	"fmt"
-	"os/exec"
 	"strings"
+	"sync"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/isbm/crtview"
@@ -17,10 +17,14 @@ It is used to show the output of a called script.
 */
 
 type TeaLoggerWindowLander struct {
-	action    func(call *teaboxlib.TeaboxAPICall) string
-	statusBar *crtview.TextView
-	titleBar  *crtview.TextView
-	w         *crtview.TextView
+	action     func(call *teaboxlib.TeaboxAPICall) string
+	statusBar  *crtview.TextView
+	titleBar   *crtview.TextView
+	w          *TeaLogView
+	errw       *TeaLogView     // STDERR pane, shown only for the commands with split STDERR
+	transcript strings.Builder // STDOUT and STDERR of all commands, as they were shown
+	lines      int             // Lines in the transcript, which is kept up to the LOGGER_MAX_LINES
+	mtx        sync.Mutex
 
 	*teaCommonBaseWindowLander
 	*crtview.Flex
@@ -28,7 +32,8 @@ type TeaLoggerWindowLander struct {
 
 func NewTeaLoggerWindowLander() *TeaLoggerWindowLander {
 	c := &TeaLoggerWindowLander{
-		Flex: crtview.NewFlex(),
+		Flex:                      crtview.NewFlex(),
+		teaCommonBaseWindowLander: newTeaCommonBaseWindowLander(),
 	}
 
 	c.SetDirection(crtview.FlexRow)
@@ -41,7 +46,7 @@ func NewTeaLoggerWindowLander() *TeaLoggerWindowLander {
 	c.AddItem(c.titleBar, 1, 0, false)
 
 	// Create STDOUT logger
-	c.w = crtview.NewTextView()
+	c.w = NewTeaLogView()
 	c.w.SetBorder(false)
 	c.w.SetBorderPadding(1, 1, 2, 2)
 	c.w.SetBackgroundColor(tcell.ColorLightGray)
@@ -53,12 +58,36 @@ func NewTeaLoggerWindowLander() *TeaLoggerWindowLander {
 	})
 	c.AddItem(c.w, 0, 1, true)
 
+	// STDERR pane, hidden until needed
+	c.errw = NewTeaLogView()
+	c.errw.SetBorder(true)
+	c.errw.SetTitle(" STDERR ")
+	c.errw.SetTitleAlign(crtview.AlignLeft)
+	c.errw.SetBorderPadding(0, 0, 1, 1)
+	c.errw.SetBackgroundColor(tcell.ColorLightGray)
+	c.errw.SetBorderColor(tcell.ColorDarkGrey)
+	c.errw.SetTitleColor(tcell.ColorBlack)
+	c.errw.SetDynamicColors(true)
+	c.errw.SetTextColor(tcell.ColorBlack)
+	c.errw.SetSkipCursorReturn(true)
+	c.errw.SetChangedFunc(func() {
+		teabox.GetTeaboxApp().Draw()
+	})
+	c.AddItem(c.errw, 0, 0, false)
+
 	// Add bottom status
 	c.statusBar = crtview.NewTextView()
 	c.statusBar.SetBackgroundColor(tcell.ColorDarkGrey)
 	c.statusBar.SetTextColor(tcell.ColorBlack)
 	c.AddItem(c.statusBar, 1, 0, false)
 
+	// Keys are sent to the focused widget only, which is the text view.
+	// Besides cancelling, the output can be searched and saved.
+	c.w.SetInputCapture(c.cancelKeyCapture)
+	c.w.SetSaveText(func() string {
+		return string(crtview.StripTags([]byte(c.GetTranscript()), true, false))
+	})
+
 	// Action definition
 	c.action = func(call *teaboxlib.TeaboxAPICall) string {
 		switch call.GetClass() {
@@ -66,7 +95,10 @@ func NewTeaLoggerWindowLander() *TeaLoggerWindowLander {
 			c.statusBar.SetText(call.GetString())
 		case teaboxlib.LOGGER_TITLE:
 			c.titleBar.SetText(call.GetString())
+		default:
+			return ""
 		}
+		call.SetHandled()
 		teabox.GetTeaboxApp().Draw()
 		return ""
 	}
@@ -81,6 +113,53 @@ func (tsw *TeaLoggerWindowLander) Reset() {
 	tsw.statusBar.SetText("")
 	tsw.titleBar.SetText("")
 	tsw.w.SetText("")
+	tsw.errw.SetText("")
+	tsw.ResizeItem(tsw.errw, 0, 0)
+	tsw.resetSteps()
+
+	tsw.mtx.Lock()
+	tsw.transcript.Reset()
+	tsw.lines = 0
+	tsw.mtx.Unlock()
+}
+
+// GetTranscript returns STDOUT and STDERR of all the commands since the last reset,
+// interleaved as they were written. STDERR is marked red with the colour tags.
+func (tsw *TeaLoggerWindowLander) GetTranscript() string {
+	tsw.mtx.Lock()
+	defer tsw.mtx.Unlock()
+
+	return tsw.transcript.String()
+}
+
+// Write to the view, keeping it in the transcript as well
+func (tsw *TeaLoggerWindowLander) log(w *TeaLogView, text string) {
+	tsw.keep(text)
+	_, _ = w.Write([]byte(text))
+}
+
+// Keep the text in the transcript. Once it is too long, the oldest lines are dropped,
+// but not on every write, as this copies the whole transcript.
+func (tsw *TeaLoggerWindowLander) keep(text string) {
+	tsw.mtx.Lock()
+	defer tsw.mtx.Unlock()
+
+	tsw.transcript.WriteString(text)
+	tsw.lines += strings.Count(text, "\n")
+
+	if max := teaboxlib.LOGGER_MAX_LINES; max > 0 && tsw.lines > max+max/10 {
+		var transcript string
+		transcript, tsw.lines = logTrimLines(tsw.transcript.String(), tsw.lines, max)
+		tsw.transcript.Reset()
+		tsw.transcript.WriteString(transcript)
+	}
+}
+
+// SetStepState of a command in a chain. Each change of the state is logged
+// as a marker line between the outputs of the commands.
+func (tsw *TeaLoggerWindowLander) SetStepState(idx int, title string, state int) {
+	tsw.setStep(idx, title, state)
+	tsw.log(tsw.w, fmt.Sprintf("\n[::b]%s[::-]\n", tsw.formatStep(idx)))
 }
 
 func (tsw *TeaLoggerWindowLander) AsWidgetPrimitive() crtview.Primitive {
@@ -89,27 +168,101 @@ func (tsw *TeaLoggerWindowLander) AsWidgetPrimitive() crtview.Primitive {
 }
 
 func (tsw *TeaLoggerWindowLander) GetWindow() *crtview.TextView {
-	return tsw.w
+	return tsw.w.TextView
 }
 
 func (tsw *TeaLoggerWindowLander) GetWindowAction() func(call *teaboxlib.TeaboxAPICall) string {
 	return tsw.action
 }
 
-func (tsw *TeaLoggerWindowLander) Action(cmdpath string, cmdargs ...string) error {
-	cmd := exec.Command(cmdpath, cmdargs...)
-	cmd.Stdout = tsw.GetWindow()
+// Draw the logger, keeping the size of the pseudo-terminal of the running command the same as the logger
+func (tsw *TeaLoggerWindowLander) Draw(screen tcell.Screen) {
+	tsw.Flex.Draw(screen)
+	tsw.resizePty(tsw.w.TextView)
+}
+
+func (tsw *TeaLoggerWindowLander) Action(modcmd *teaboxlib.TeaConfModCommand, cmdargs ...string) error {
+	if modcmd.IsPty() {
+		// Tools keep their progress output and colours, as they would do in a terminal.
+		// The terminal re-renders the whole view, so only what was added is kept in the transcript.
+		before := tsw.w.GetText(false)
+		err := tsw.runPty(tsw.w.TextView, modcmd, cmdargs...)
+		tsw.keep(strings.TrimPrefix(strings.TrimPrefix(tsw.w.GetText(false), before), "\n"))
+
+		return err
+	}
+
+	cmdpath := modcmd.GetCommandPath()
+	cmd := tsw.command(modcmd, cmdpath, cmdargs...)
+
+	// STDERR goes to the view as well, but in red. Colour escapes of both are shown as colours,
+	// while STDOUT can also have the colour tags.
+	errw := tsw.w
+	if modcmd.GetStderrMode() == teaboxlib.STDERR_SPLIT {
+		errw = tsw.errw
+		tsw.ResizeItem(tsw.errw, 0, 1)
+	}
+	stderr := &teaLoggerStderr{
+		w: NewTeaANSIWriter(&teaLoggerView{tsw: tsw, w: errw}, errw.GetBackgroundColor()).SetForeground(tcell.ColorRed).SetEscaping(true),
+	}
+	cmd.Stdout = NewTeaANSIWriter(&teaLoggerView{tsw: tsw, w: tsw.w}, tsw.w.GetBackgroundColor())
+	cmd.Stderr = stderr
 
-	buf := new(strings.Builder)
-	cmd.Stderr = buf
+	if err := tsw.startCommand(cmd, modcmd.GetTimeout()); err != nil {
+		return fmt.Errorf("Error: command \"%s %s\" quit as %s", cmdpath, strings.Join(cmdargs, " "), err.Error())
+	}
 
-	err := cmd.Run()
-	if err != nil {
+	err := tsw.waitCommand(cmd)
+	stderr.flush()
+
+	if isLanderStop(err) {
+		return err
+	} else if err != nil {
 		teabox.AddToFile(teaboxlib.LOG_FILENAME,
 			fmt.Sprintf("Error: command \"%s %s\" quit as %s\nOutput: %s",
-				cmdpath, strings.Join(cmdargs, " "), err.Error(), buf.String()))
-		return fmt.Errorf(buf.String())
+				cmdpath, strings.Join(cmdargs, " "), err.Error(), termStripEscapes(stderr.buf.String())))
+		return tsw.exitError(err, termStripEscapes(stderr.buf.String()))
 	}
 
 	return nil
 }
+
+// Output of the command, written to a view of the logger and kept in the transcript
+type teaLoggerView struct {
+	tsw *TeaLoggerWindowLander
+	w   *TeaLogView
+}
+
+func (v *teaLoggerView) Write(p []byte) (int, error) {
+	v.tsw.log(v.w, string(p))
+	return len(p), nil
+}
+
+// STDERR of the command, written to the logger in red. It is written by whole lines (or "\r" updates),
+// so the escaping of the text, which looks like colour tags, is not broken in between of the writes.
+type teaLoggerStderr struct {
+	w       *TeaANSIWriter
+	buf     strings.Builder // Whole STDERR, explaining the failure
+	pending string
+}
+
+func (e *teaLoggerStderr) Write(p []byte) (int, error) {
+	e.buf.Write(p)
+
+	data := e.pending + string(p)
+	idx := strings.LastIndexAny(data, "\n\r")
+	e.pending = data[idx+1:]
+	if idx >= 0 {
+		_, _ = e.w.Write([]byte(data[:idx+1]))
+	}
+
+	return len(p), nil
+}
+
+// Write the rest of the output, which is not ended by a new line
+func (e *teaLoggerStderr) flush() {
+	if e.pending != "" {
+		_, _ = e.w.Write([]byte(e.pending))
+		e.pending = ""
+	}
+}
@@ -3,7 +3,6 @@ package teawidgets
 import (
 	"bufio"
 	"fmt"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -141,6 +140,7 @@ type TeaProgressWindowLander struct {
 	checklist   *landerChecklist
 	action      func(call *teaboxlib.TeaboxAPICall) string
 	title       *crtview.TextView    // Title of the lander page
+	stepsView   *crtview.TextView    // Steps of a command chain and their states
 	eventBar    *crtview.TextView    // Like a status bar, but shows a chunk of the progress
 	generalInfo *crtview.TextView    // General text information (static per module)
 	progressBar *crtview.ProgressBar // Progressbar itself
@@ -151,12 +151,14 @@ type TeaProgressWindowLander struct {
 
 func NewTeaProgressWindowLander() *TeaProgressWindowLander {
 	return (&TeaProgressWindowLander{
-		Flex:        crtview.NewFlex(),
-		checklist:   meowLanderChecklist(),
-		title:       crtview.NewTextView(),
-		eventBar:    crtview.NewTextView(),
-		generalInfo: crtview.NewTextView(),
-		progressBar: crtview.NewProgressBar(),
+		Flex:                      crtview.NewFlex(),
+		teaCommonBaseWindowLander: newTeaCommonBaseWindowLander(),
+		checklist:                 meowLanderChecklist(),
+		title:                     crtview.NewTextView(),
+		stepsView:                 crtview.NewTextView(),
+		eventBar:                  crtview.NewTextView(),
+		generalInfo:               crtview.NewTextView(),
+		progressBar:               crtview.NewProgressBar(),
 	}).init()
 }
 
@@ -169,6 +171,13 @@ func (pl *TeaProgressWindowLander) init() *TeaProgressWindowLander {
 	pl.title.SetBorderPadding(1, 1, 10, 10)
 	pl.AddItem(pl.title, 3, 1, false)
 
+	// Steps of a chain, hidden while there is none
+	pl.stepsView.SetBackgroundColor(teaboxlib.WORKSPACE_BACKGROUND)
+	pl.stepsView.SetTextColor(teaboxlib.WORKSPACE_HEADER_TEXT)
+	pl.stepsView.SetDynamicColors(true)
+	pl.stepsView.SetBorderPadding(0, 0, 10, 10)
+	pl.AddItem(pl.stepsView, 0, 0, false)
+
 	// Mid spacer
 	spacer := crtview.NewTextView()
 	spacer.SetBackgroundColor(teaboxlib.WORKSPACE_BACKGROUND)
@@ -217,6 +226,7 @@ func (pl *TeaProgressWindowLander) init() *TeaProgressWindowLander {
 	pgbPadder.AddItem(spacer, 10, 1, false)
 
 	pl.AddItem(pgbPadder, 6, 1, false)
+	pl.SetInputCapture(pl.cancelKeyCapture)
 
 	// Bottom spacer
 	//pl.AddItem(spacer, 4, 1, false)
@@ -231,7 +241,9 @@ func (pl *TeaProgressWindowLander) init() *TeaProgressWindowLander {
 			if pl.stepsOffset >= pl.steps {
 				pl.stepsOffset = pl.steps
 			}
-			pl.progressBar.SetProgress((100 / pl.steps) * pl.stepsOffset)
+			if pl.steps > 0 {
+				pl.progressBar.SetProgress((100 / pl.steps) * pl.stepsOffset)
+			}
 		case teaboxlib.COMMON_PROGRESS_ALLOCATE:
 			pl.steps = call.GetInt()
 		case teaboxlib.COMMON_PROGRESS_SET:
@@ -260,8 +272,11 @@ func (pl *TeaProgressWindowLander) init() *TeaProgressWindowLander {
 			pl.title.SetText(call.GetString())
 		case teaboxlib.COMMON_RESET:
 			pl.Reset()
+		default:
+			return ""
 		}
 
+		call.SetHandled()
 		teabox.GetTeaboxApp().Draw()
 		return ""
 	}
@@ -269,13 +284,14 @@ func (pl *TeaProgressWindowLander) init() *TeaProgressWindowLander {
 	return pl
 }
 
-func (pl *TeaProgressWindowLander) Action(cmdpath string, cmdargs ...string) error {
-	cmd := exec.Command(cmdpath, cmdargs...)
+func (pl *TeaProgressWindowLander) Action(modcmd *teaboxlib.TeaConfModCommand, cmdargs ...string) error {
+	cmdpath := modcmd.GetCommandPath()
+	cmd := pl.command(modcmd, cmdpath, cmdargs...)
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return fmt.Errorf(fmt.Sprintf("Error: cannot initialise communication for the %s: %s", cmdpath, err.Error()))
 	}
-	if err := cmd.Start(); err != nil {
+	if err := pl.startCommand(cmd, modcmd.GetTimeout()); err != nil {
 		return fmt.Errorf(fmt.Sprintf("Error: command \"%s %s\" quit as %s", cmdpath, strings.Join(cmdargs, " "), err.Error()))
 	}
 
@@ -296,8 +312,10 @@ func (pl *TeaProgressWindowLander) Action(cmdpath string, cmdargs ...string) err
 		}
 	}
 
-	if err := cmd.Wait(); err != nil {
-		return fmt.Errorf(fmt.Sprintf("Error running %s: %s", cmdpath, err.Error()))
+	if err := pl.waitCommand(cmd); isLanderStop(err) {
+		return err
+	} else if err != nil {
+		return pl.exitError(err, fmt.Sprintf("Error running %s: %s", cmdpath, err.Error()))
 	}
 
 	teabox.GetTeaboxApp().Draw()
@@ -327,4 +345,15 @@ func (pl *TeaProgressWindowLander) Reset() {
 
 	pl.title.SetText("Welcome!")
 	pl.eventBar.SetText("")
+
+	pl.resetSteps()
+	pl.stepsView.SetText("")
+	pl.ResizeItem(pl.stepsView, 0, 0)
+}
+
+// SetStepState of a command in a chain and show all the steps under the title
+func (pl *TeaProgressWindowLander) SetStepState(idx int, title string, state int) {
+	pl.setStep(idx, title, state)
+	pl.stepsView.SetText(pl.getStepsReport())
+	pl.ResizeItem(pl.stepsView, len(pl.stepsIdx)+1, 0)
 }
@@ -0,0 +1,122 @@
+package teawidgets
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/isbm/crtview"
+	"gitlab.com/isbm/teabox"
+	"gitlab.com/isbm/teabox/teaboxlib"
+)
+
+/*
+TeaTerminalWindowLander is a lander page with an embedded terminal. The command always
+runs in a pseudo-terminal and the user can interact with it: all keys, including Ctrl+C,
+are sent to the command while it runs. Once it is finished, the output can be scrolled.
+*/
+
+type TeaTerminalWindowLander struct {
+	action    func(call *teaboxlib.TeaboxAPICall) string
+	titleBar  *crtview.TextView
+	statusBar *crtview.TextView
+	screen    *TeaTerminalView
+
+	*teaCommonBaseWindowLander
+	*crtview.Flex
+}
+
+// NewTeaTerminalWindowLander constructor
+func NewTeaTerminalWindowLander() *TeaTerminalWindowLander {
+	return (&TeaTerminalWindowLander{
+		Flex:                      crtview.NewFlex(),
+		teaCommonBaseWindowLander: newTeaCommonBaseWindowLander(),
+		titleBar:                  crtview.NewTextView(),
+		statusBar:                 crtview.NewTextView(),
+		screen:                    NewTeaTerminalView(),
+	}).init()
+}
+
+func (tl *TeaTerminalWindowLander) init() *TeaTerminalWindowLander {
+	tl.interactive = true
+	tl.SetDirection(crtview.FlexRow)
+
+	// Top label
+	tl.titleBar.SetBackgroundColor(tcell.NewRGBColor(0x88, 0x88, 0x88))
+	tl.titleBar.SetTextColor(tcell.ColorBlack)
+	tl.AddItem(tl.titleBar, 1, 0, false)
+
+	// Terminal screen, just like a console
+	tl.screen.SetBorder(false)
+	tl.screen.SetBorderPadding(0, 0, 1, 1)
+	tl.screen.SetBackgroundColor(tcell.ColorBlack)
+	tl.screen.SetTextColor(tcell.ColorLightGray)
+	tl.screen.SetDynamicColors(true)
+	tl.screen.SetSkipCursorReturn(true)
+	tl.AddItem(tl.screen, 0, 1, true)
+
+	// Bottom status
+	tl.statusBar.SetBackgroundColor(tcell.ColorDarkGrey)
+	tl.statusBar.SetTextColor(tcell.ColorBlack)
+	tl.AddItem(tl.statusBar, 1, 0, false)
+
+	// Define API receiver
+	tl.action = func(call *teaboxlib.TeaboxAPICall) string {
+		switch call.GetClass() {
+		case teaboxlib.TERMINAL_TITLE:
+			tl.titleBar.SetText(call.GetString())
+		case teaboxlib.TERMINAL_STATUS:
+			tl.statusBar.SetText(call.GetString())
+		default:
+			return ""
+		}
+		call.SetHandled()
+		teabox.GetTeaboxApp().Draw()
+		return ""
+	}
+
+	tl.Reset()
+
+	return tl
+}
+
+// Reset all content to the initial values
+func (tl *TeaTerminalWindowLander) Reset() {
+	tl.titleBar.SetText("")
+	tl.statusBar.SetText("")
+	tl.screen.SetText("")
+	tl.screen.SetInput(nil)
+	tl.resetSteps()
+}
+
+// SetStepState of a command in a chain, displaying it on the status bar
+func (tl *TeaTerminalWindowLander) SetStepState(idx int, title string, state int) {
+	tl.setStep(idx, title, state)
+	tl.statusBar.SetText(tl.formatStep(idx))
+}
+
+func (tl *TeaTerminalWindowLander) AsWidgetPrimitive() crtview.Primitive {
+	var w TeaboxLandingWindow = tl
+	return w.(crtview.Primitive)
+}
+
+// Return window receiver action on Unix socket calls, specific per this widget.
+func (tl *TeaTerminalWindowLander) GetWindowAction() func(call *teaboxlib.TeaboxAPICall) string {
+	return tl.action
+}
+
+// Draw the lander, keeping the size of the pseudo-terminal of the running command the same as the screen
+func (tl *TeaTerminalWindowLander) Draw(screen tcell.Screen) {
+	tl.Flex.Draw(screen)
+	tl.resizePty(tl.screen.TextView)
+}
+
+// Action runs the command in a pseudo-terminal, sending all the keys to it while it runs
+func (tl *TeaTerminalWindowLander) Action(modcmd *teaboxlib.TeaConfModCommand, cmdargs ...string) error {
+	tl.screen.SetInput(tl.writePty)
+	defer tl.screen.SetInput(nil)
+
+	// Run in the background does not take the keys of whatever the user is doing. Once it is
+	// shown, the screen is focused with the lander.
+	if tl.isForeground() {
+		teabox.GetTeaboxApp().SetFocus(tl.screen)
+	}
+	return tl.runPty(tl.screen.TextView, modcmd, cmdargs...)
+}
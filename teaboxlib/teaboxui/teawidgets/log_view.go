@@ -0,0 +1,321 @@
+package teawidgets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/isbm/crtview"
+	"github.com/isbm/crtview/crtwin"
+	"gitlab.com/isbm/teabox/teaboxlib"
+)
+
+// Modes of the log view
+const (
+	logViewBrowse = iota
+	logViewSearch
+	logViewSave
+)
+
+/*
+TeaLogView is a text view of a log, which can be searched and saved to a file:
+
+  - "/" starts an incremental search, "n" jumps to the next match
+  - "s" asks for a path and saves the text there
+
+The matching line is highlighted. The text is kept up to the LOGGER_MAX_LINES lines,
+the older ones are dropped.
+*/
+type TeaLogView struct {
+	mode     int
+	term     string // Search term, lowercase
+	match    int    // Line of the current match, or -1
+	origin   int    // Line, where the search started
+	notice   string // One-time message at the bottom, e.g. where the text was saved
+	saveText func() string
+
+	prompt     *crtview.InputField
+	saveDialog *crtwin.DialogWindow
+	savePath   *crtview.InputField
+
+	*crtview.TextView
+}
+
+// NewTeaLogView constructor
+func NewTeaLogView() *TeaLogView {
+	return (&TeaLogView{
+		TextView:   crtview.NewTextView(),
+		prompt:     crtview.NewInputField(),
+		saveDialog: crtwin.NewDialogWindow(),
+		savePath:   crtview.NewInputField(),
+		match:      -1,
+	}).init()
+}
+
+func (lv *TeaLogView) init() *TeaLogView {
+	lv.SetMaxLines(teaboxlib.LOGGER_MAX_LINES)
+
+	// Search prompt over the last line of the view
+	lv.prompt.SetLabel("Search: ")
+	lv.prompt.SetBackgroundColor(tcell.ColorDarkGrey)
+	lv.prompt.SetLabelColor(tcell.ColorBlack)
+	lv.prompt.SetFieldBackgroundColor(tcell.ColorDarkGrey)
+	lv.prompt.SetFieldBackgroundColorFocused(tcell.ColorDarkGrey)
+	lv.prompt.SetFieldTextColor(tcell.ColorBlack)
+	lv.prompt.SetFieldTextColorFocused(tcell.ColorBlack)
+	lv.prompt.SetChangedFunc(func(text string) {
+		lv.term = strings.ToLower(text)
+		lv.find(lv.origin, false)
+	})
+	lv.prompt.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			lv.term = ""
+			lv.match = -1
+		}
+		lv.setMode(logViewBrowse)
+	})
+
+	// Dialog, asking where the text should be saved
+	lv.saveDialog.SetTitle("Save to a file")
+	lv.saveDialog.SetCentered(true)
+	lv.saveDialog.SetBackgroundColor(crtview.Styles.InfoDialogBackgroundColor)
+	lv.saveDialog.SetBorderColor(crtview.Styles.InfoDialogBorderColor)
+	lv.saveDialog.SetBorderColorFocused(crtview.Styles.InfoDialogBorderColor)
+	lv.saveDialog.SetTitleColor(crtview.Styles.InfoDialogBorderColor)
+	lv.saveDialog.SetLabelColor(crtview.Styles.InfoDialogTextColor)
+	lv.saveDialog.SetFieldBackgroundColor(teaboxlib.FORM_FIELD_BACKGROUND_FOCUSED)
+	lv.saveDialog.SetFieldTextColor(teaboxlib.FORM_FIELD_TEXT)
+	lv.savePath.SetLabel("Path: ")
+	lv.savePath.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			if err := lv.save(lv.savePath.GetText()); err != nil {
+				lv.saveDialog.SetTitle(err.Error())
+				return
+			}
+			lv.notice = fmt.Sprintf("Saved to %s", lv.savePath.GetText())
+		}
+		lv.setMode(logViewBrowse)
+	})
+	lv.saveDialog.AddFormItem(lv.savePath)
+
+	return lv
+}
+
+// SetSaveText sets a function, returning the text to be saved. By default, it is the text of the view.
+func (lv *TeaLogView) SetSaveText(text func() string) *TeaLogView {
+	lv.saveText = text
+	return lv
+}
+
+// SetText of the view, forgetting the last search
+func (lv *TeaLogView) SetText(text string) *crtview.TextView {
+	lv.term, lv.match, lv.notice = "", -1, ""
+	lv.setMode(logViewBrowse)
+
+	return lv.TextView.SetText(text)
+}
+
+// IsGrabbingKeys returns true while the search or the path is typed in
+func (lv *TeaLogView) IsGrabbingKeys() bool {
+	return lv.mode != logViewBrowse
+}
+
+// Switch the mode, moving the cursor focus to the prompt of it
+func (lv *TeaLogView) setMode(mode int) {
+	lv.prompt.Blur()
+	lv.savePath.Blur()
+
+	lv.mode = mode
+	switch mode {
+	case logViewSearch:
+		lv.prompt.SetText("")
+		lv.prompt.SetLabel("Search: ")
+		lv.prompt.Focus(func(p crtview.Primitive) {})
+	case logViewSave:
+		lv.saveDialog.SetTitle("Save to a file")
+		lv.savePath.SetText(filepath.Join(os.TempDir(), fmt.Sprintf("teabox-%s.log", time.Now().Format("20060102-150405"))))
+		lv.savePath.Focus(func(p crtview.Primitive) {})
+	}
+}
+
+// Save the text to the file
+func (lv *TeaLogView) save(path string) error {
+	text := lv.GetText(true)
+	if lv.saveText != nil {
+		text = lv.saveText()
+	}
+
+	if path == "" {
+		return fmt.Errorf("Path is not specified")
+	}
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		return fmt.Errorf("Unable to save: %s", err.Error())
+	}
+
+	return nil
+}
+
+// Lines of the text with the colour tags
+func (lv *TeaLogView) lines() []string {
+	return strings.Split(lv.GetText(false), "\n")
+}
+
+// Number of rows the line takes in the view, as it is wrapped by the width
+func (lv *TeaLogView) rows(line string, width int) int {
+	if width < 1 {
+		return 1
+	}
+	if n := (crtview.TaggedStringWidth(line) + width - 1) / width; n > 1 {
+		return n
+	}
+
+	return 1
+}
+
+// Find the search term from the line on, wrapping around at the end. The view is scrolled
+// to the matching line. Returns false, if there is no match.
+func (lv *TeaLogView) find(from int, next bool) bool {
+	lines := lv.lines()
+	if next {
+		from++
+	}
+
+	lv.match = -1
+	for idx := 0; lv.term != "" && idx < len(lines); idx++ {
+		line := (from + idx) % len(lines)
+		if strings.Contains(strings.ToLower(string(crtview.StripTags([]byte(lines[line]), true, false))), lv.term) {
+			lv.match = line
+			break
+		}
+	}
+
+	if lv.match < 0 {
+		if lv.term != "" {
+			lv.prompt.SetLabel("Not found: ")
+		}
+		return false
+	}
+
+	lv.prompt.SetLabel("Search: ")
+	_, _, width, _ := lv.GetInnerRect()
+	row := 0
+	for _, line := range lines[:lv.match] {
+		row += lv.rows(line, width)
+	}
+	lv.ScrollTo(row, 0)
+
+	return true
+}
+
+// Line, shown at the top of the view
+func (lv *TeaLogView) topLine() int {
+	_, _, width, _ := lv.GetInnerRect()
+	offset, _ := lv.GetScrollOffset()
+	for idx, line := range lv.lines() {
+		if offset -= lv.rows(line, width); offset < 0 {
+			return idx
+		}
+	}
+
+	return 0
+}
+
+// InputHandler sends the keys to the prompt while it is shown, otherwise it is a usual text view
+func (lv *TeaLogView) InputHandler() func(event *tcell.EventKey, setFocus func(p crtview.Primitive)) {
+	handler := lv.TextView.InputHandler()
+	return func(event *tcell.EventKey, setFocus func(p crtview.Primitive)) {
+		lv.notice = ""
+
+		switch {
+		case lv.mode == logViewSearch:
+			lv.prompt.InputHandler()(event, setFocus)
+		case lv.mode == logViewSave:
+			lv.savePath.InputHandler()(event, setFocus)
+		case event.Key() == tcell.KeyRune && event.Rune() == '/':
+			lv.origin = lv.topLine()
+			lv.setMode(logViewSearch)
+		case event.Key() == tcell.KeyRune && event.Rune() == 'n' && lv.term != "":
+			from := lv.match
+			if from < 0 {
+				from = lv.topLine()
+			}
+			lv.find(from, true)
+		case event.Key() == tcell.KeyRune && event.Rune() == 's':
+			lv.setMode(logViewSave)
+		default:
+			handler(event, setFocus)
+		}
+	}
+}
+
+// Draw the view with the highlighted match, and the prompt or the dialog over it
+func (lv *TeaLogView) Draw(screen tcell.Screen) {
+	lv.TextView.Draw(screen)
+
+	x, y, width, height := lv.GetInnerRect()
+	if lv.match >= 0 && lv.term != "" {
+		lv.highlight(screen, x, y, width, height)
+	}
+
+	switch {
+	case lv.mode == logViewSearch:
+		lv.prompt.SetRect(x, y+height-1, width, 1)
+		lv.prompt.Draw(screen)
+	case lv.mode == logViewSave:
+		sw, _ := screen.Size()
+		lv.saveDialog.SetSize(sw*2/3, 7)
+		lv.saveDialog.Draw(screen)
+	case lv.notice != "":
+		style := tcell.StyleDefault.Background(tcell.ColorDarkGrey).Foreground(tcell.ColorBlack)
+		for c := 0; c < width; c++ {
+			screen.SetContent(x+c, y+height-1, ' ', nil, style)
+		}
+		crtview.PrintStyle(screen, []byte(crtview.Escape(lv.notice)), x, y+height-1, width, crtview.AlignLeft, style)
+	}
+}
+
+// Reverse the colours of the rows of the matching line, which are visible
+func (lv *TeaLogView) highlight(screen tcell.Screen, x, y, width, height int) {
+	lines := lv.lines()
+	if lv.match >= len(lines) {
+		return
+	}
+
+	row := 0
+	for _, line := range lines[:lv.match] {
+		row += lv.rows(line, width)
+	}
+	offset, _ := lv.GetScrollOffset()
+	row -= offset
+
+	for r := row; r < row+lv.rows(lines[lv.match], width); r++ {
+		if r < 0 || r >= height {
+			continue
+		}
+		for c := 0; c < width; c++ {
+			main, comb, style, _ := screen.GetContent(x+c, y+r)
+			screen.SetContent(x+c, y+r, main, comb, style.Reverse(true))
+		}
+	}
+}
+
+// Drop the first lines of the text, so it has the "max" lines at most. Zero means no limit.
+// Returns the text and the number of its lines.
+func logTrimLines(text string, lines, max int) (string, int) {
+	if max <= 0 || lines <= max {
+		return text, lines
+	}
+
+	for ; lines > max; lines-- {
+		idx := strings.IndexByte(text, '\n')
+		if idx < 0 {
+			break
+		}
+		text = text[idx+1:]
+	}
+
+	return text, lines
+}
@@ -0,0 +1,161 @@
+package teawidgets
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/isbm/crtview"
+	"gitlab.com/isbm/teabox"
+	"gitlab.com/isbm/teabox/teaboxlib"
+	"golang.org/x/sys/unix"
+)
+
+// Open a new pseudo-terminal, returning its master and slave sides
+func openPty() (*os.File, *os.File, error) {
+	ptmx, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Calling Fd() would put the master into the blocking mode, so its Read cannot be interrupted by Close
+	var ptn int
+	ctl := func(fd uintptr) {
+		if err = unix.IoctlSetPointerInt(int(fd), unix.TIOCSPTLCK, 0); err == nil {
+			ptn, err = unix.IoctlGetInt(int(fd), unix.TIOCGPTN)
+		}
+	}
+	if rawConn, cerr := ptmx.SyscallConn(); cerr != nil {
+		err = cerr
+	} else if cerr = rawConn.Control(ctl); cerr != nil {
+		err = cerr
+	}
+	if err != nil {
+		ptmx.Close()
+		return nil, nil, err
+	}
+
+	tty, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", ptn), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		ptmx.Close()
+		return nil, nil, err
+	}
+
+	return ptmx, tty, nil
+}
+
+// Set the size of the pseudo-terminal. The command gets SIGWINCH on change.
+func setPtySize(ptmx *os.File, rows, cols int) error {
+	rawConn, err := ptmx.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	if cerr := rawConn.Control(func(fd uintptr) {
+		err = unix.IoctlSetWinsize(int(fd), unix.TIOCSWINSZ, &unix.Winsize{Row: uint16(rows), Col: uint16(cols)})
+	}); cerr != nil {
+		return cerr
+	}
+
+	return err
+}
+
+// Keep the size of the pseudo-terminal of the running command the same as the text view, showing its output
+func (base *teaCommonBaseWindowLander) resizePty(w *crtview.TextView) {
+	base.ptyMtx.Lock()
+	defer base.ptyMtx.Unlock()
+
+	if base.pty != nil {
+		if _, _, cols, rows := w.GetInnerRect(); base.term.SetSize(rows, cols) {
+			rows, cols = base.term.GetSize()
+			_ = setPtySize(base.pty, rows, cols)
+		}
+	}
+}
+
+// Send the input to the command, running in the pseudo-terminal, as if it was typed on a keyboard
+func (base *teaCommonBaseWindowLander) writePty(data []byte) {
+	base.ptyMtx.Lock()
+	defer base.ptyMtx.Unlock()
+
+	if base.pty != nil {
+		_, _ = base.pty.Write(data)
+	}
+}
+
+// Run the command in a pseudo-terminal of the size of the text view, which shows its output the way
+// a terminal would do it. STDOUT and STDERR are not separated then.
+func (base *teaCommonBaseWindowLander) runPty(w *crtview.TextView, modcmd *teaboxlib.TeaConfModCommand, cmdargs ...string) error {
+	cmdpath := modcmd.GetCommandPath()
+	ptmx, tty, err := openPty()
+	if err != nil {
+		return fmt.Errorf("Error: cannot open a terminal for the %s: %s", cmdpath, err.Error())
+	}
+	defer ptmx.Close()
+
+	_, _, cols, rows := w.GetInnerRect()
+	term := NewTeaTerminalWriter(w, rows, cols)
+	term.SetCursorVisible(base.interactive)
+	rows, cols = term.GetSize()
+	if err := setPtySize(ptmx, rows, cols); err != nil {
+		tty.Close()
+		return fmt.Errorf("Error: cannot set the terminal size for the %s: %s", cmdpath, err.Error())
+	}
+
+	cmd := base.command(modcmd, cmdpath, cmdargs...)
+	if _, ok := modcmd.GetEnv()["TERM"]; !ok {
+		cmd.Env = append(cmd.Env, "TERM=xterm-256color")
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = tty, tty, tty
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+
+	err = base.startCommand(cmd, modcmd.GetTimeout())
+	tty.Close() // Only the command holds the terminal now
+	if err != nil {
+		return fmt.Errorf("Error: command \"%s %s\" quit as %s", cmdpath, strings.Join(cmdargs, " "), err.Error())
+	}
+
+	base.ptyMtx.Lock()
+	base.pty, base.term = ptmx, term
+	base.ptyMtx.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(term, ptmx)
+		close(done)
+	}()
+
+	err = base.waitCommand(cmd)
+
+	// Drain the rest of the output, but do not wait for the background children, still holding the terminal
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+	}
+
+	base.ptyMtx.Lock()
+	base.pty, base.term = nil, nil
+	base.ptyMtx.Unlock()
+	ptmx.Close()
+	<-done
+
+	if base.interactive {
+		term.SetCursorVisible(false) // The command is gone, so is its cursor
+		_, _ = term.Write(nil)
+	}
+
+	if isLanderStop(err) {
+		return err
+	} else if err != nil {
+		output := term.GetLastLines(10) // STDERR is on the screen, the last lines are explaining the failure
+		teabox.AddToFile(teaboxlib.LOG_FILENAME,
+			fmt.Sprintf("Error: command \"%s %s\" quit as %s\nOutput: %s",
+				cmdpath, strings.Join(cmdargs, " "), err.Error(), output))
+		return base.exitError(err, output)
+	}
+
+	return nil
+}
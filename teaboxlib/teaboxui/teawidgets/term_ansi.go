@@ -0,0 +1,194 @@
+package teawidgets
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/isbm/crtview"
+)
+
+/*
+TeaANSIWriter translates the ANSI colour sequences (SGR) of the output to crtview colour tags,
+so the output of the tools, written for a usual terminal, is coloured in a text view with the
+dynamic colours, instead of showing the escapes as garbage. Supported are 16, 256 and true
+colours, bold and the other attributes, and reset. Other escape sequences are dropped.
+
+Each write is complete on its own: it starts with the current colour and ends with a reset,
+so the writers of different streams can write to the same text view.
+*/
+type TeaANSIWriter struct {
+	w       io.Writer
+	bg      string // Background of the text view, as a colour tag
+	fg      string // Foreground, if the output does not set any
+	escape  bool   // Text is escaped, so it is not taken as colour tags
+	style   termStyle
+	pending []byte // Incomplete escape sequence, left from the previous write
+	mtx     sync.Mutex
+}
+
+// NewTeaANSIWriter constructor. The background is the one of the text view, the writer is in front of.
+func NewTeaANSIWriter(w io.Writer, bg tcell.Color) *TeaANSIWriter {
+	return &TeaANSIWriter{w: w, bg: termColorName(bg)}
+}
+
+// SetForeground colour of the text, which is not coloured by the output itself
+func (aw *TeaANSIWriter) SetForeground(color tcell.Color) *TeaANSIWriter {
+	aw.mtx.Lock()
+	defer aw.mtx.Unlock()
+
+	aw.fg = termColorName(color)
+	return aw
+}
+
+// SetEscaping of the text. If the output is not expected to have crtview colour tags,
+// anything looking like them is escaped and shown as is.
+func (aw *TeaANSIWriter) SetEscaping(escape bool) *TeaANSIWriter {
+	aw.mtx.Lock()
+	defer aw.mtx.Unlock()
+
+	aw.escape = escape
+	return aw
+}
+
+// Current style with the default foreground
+func (aw *TeaANSIWriter) current() termStyle {
+	style := aw.style
+	if style.fg == "" {
+		style.fg = aw.fg
+	}
+	return style
+}
+
+// Write the output, translating the colour sequences
+func (aw *TeaANSIWriter) Write(p []byte) (int, error) {
+	aw.mtx.Lock()
+	defer aw.mtx.Unlock()
+
+	data := append(aw.pending, p...)
+	aw.pending = nil
+
+	var out strings.Builder
+	if aw.current() != (termStyle{}) {
+		out.WriteString(aw.current().tag(aw.bg))
+	}
+
+	for len(data) > 0 {
+		idx := strings.IndexByte(string(data), 0x1b)
+		if idx < 0 {
+			idx = len(data)
+		}
+		if text := string(data[:idx]); aw.escape {
+			out.WriteString(crtview.Escape(text))
+		} else {
+			out.WriteString(text)
+		}
+		data = data[idx:]
+		if len(data) == 0 {
+			break
+		}
+
+		n := termEscapeLen(data)
+		if n == 0 {
+			aw.pending = append([]byte{}, data...)
+			break
+		}
+		if data[1] == '[' && n > 2 && data[n-1] == 'm' {
+			if args, ok := termCSIArgs(string(data[2 : n-1])); ok {
+				aw.style.applySGR(args)
+				out.WriteString(aw.current().tag(aw.bg))
+			}
+		}
+		data = data[n:]
+	}
+
+	if aw.current() != (termStyle{}) {
+		out.WriteString(termStyle{}.tag(aw.bg))
+	}
+
+	if _, err := io.WriteString(aw.w, out.String()); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Length of the escape sequence at the start of the data, or 0 if it is incomplete.
+// Broken sequences are skipped by their first two bytes.
+func termEscapeLen(seq []byte) int {
+	if len(seq) < 2 {
+		return 0
+	}
+
+	switch seq[1] {
+	case '[': // CSI
+		for idx := 2; idx < len(seq); idx++ {
+			if seq[idx] >= 0x40 && seq[idx] <= 0x7e {
+				return idx + 1
+			}
+		}
+		if len(seq) > 64 { // Broken sequence, skip it
+			return 2
+		}
+		return 0
+	case ']', 'P', '_', '^': // OSC and other strings, terminated by BEL or ST
+		for idx := 2; idx < len(seq); idx++ {
+			if seq[idx] == 0x07 {
+				return idx + 1
+			} else if seq[idx] == 0x1b && idx+1 < len(seq) && seq[idx+1] == '\\' {
+				return idx + 2
+			}
+		}
+		if len(seq) > 4096 {
+			return 2
+		}
+		return 0
+	case '(', ')', '*', '+', '#', '%': // Character sets
+		if len(seq) < 3 {
+			return 0
+		}
+		return 3
+	}
+
+	return 2
+}
+
+// Parameters of the control sequence "ESC [ params final". Returns false for private
+// sequences and the ones with intermediate bytes, which are not supported.
+func termCSIArgs(params string) ([]int, bool) {
+	if params != "" && strings.ContainsAny(params[:1], "?<=>") {
+		return nil, false
+	}
+	if strings.ContainsAny(params, " !\"#$%&'()*+,-./") {
+		return nil, false
+	}
+
+	args := []int{}
+	if params != "" {
+		for _, p := range strings.Split(strings.ReplaceAll(params, ":", ";"), ";") {
+			v, _ := strconv.Atoi(p) // Empty parameter is zero
+			args = append(args, v)
+		}
+	}
+
+	return args, true
+}
+
+// Remove the escape sequences from the text, e.g. for the error messages
+func termStripEscapes(text string) string {
+	var out strings.Builder
+	for data := []byte(text); len(data) > 0; {
+		if data[0] != 0x1b {
+			out.WriteByte(data[0])
+			data = data[1:]
+		} else if n := termEscapeLen(data); n > 0 {
+			data = data[n:]
+		} else {
+			break // Incomplete sequence at the end
+		}
+	}
+
+	return out.String()
+}
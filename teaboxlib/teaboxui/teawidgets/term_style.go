@@ -0,0 +1,131 @@
+package teawidgets
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"gitlab.com/isbm/teabox/teaboxlib"
+)
+
+// Style of the terminal output, kept as parts of a crtview colour tag.
+// Empty part means the default of the text view.
+type termStyle struct {
+	fg    string
+	bg    string
+	attrs string // crtview attribute flags, such as "b" for bold or "u" for underline
+}
+
+// Tag of the style, resetting everything that is not set. Background is reset to "defBg",
+// because crtview resets it to the default of the terminal, rather than of the widget.
+func (ts termStyle) tag(defBg string) string {
+	parts := []string{ts.fg, ts.bg, ts.attrs}
+	if parts[1] == "" {
+		parts[1] = defBg
+	}
+	for idx, part := range parts {
+		if part == "" {
+			parts[idx] = "-"
+		}
+	}
+
+	return "[" + strings.Join(parts, ":") + "]"
+}
+
+// Name of the colour for a crtview colour tag, or empty for the default colour
+func termColorName(color tcell.Color) string {
+	if color == tcell.ColorDefault || color.Hex() < 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("#%06x", color.Hex())
+}
+
+// Set or unset an attribute flag
+func (ts *termStyle) setAttr(flag byte, on bool) {
+	ts.attrs = strings.ReplaceAll(ts.attrs, string(flag), "")
+	if on {
+		ts.attrs += string(flag)
+	}
+}
+
+// Colour of the ANSI palette index. The first 16 colours are the EGA ones.
+func termColor(idx int) string {
+	var color tcell.Color
+	if idx >= 0 && idx < len(teaboxlib.EGAPalette) {
+		color = teaboxlib.EGAPalette[idx]
+	} else if idx < 256 {
+		color = tcell.PaletteColor(idx)
+	}
+
+	return termColorName(color)
+}
+
+// Parse extended colour parameters after 38 or 48: either "5;idx" or "2;r;g;b".
+// Returns the colour and the number of consumed parameters.
+func termExtColor(params []int) (string, int) {
+	if len(params) >= 2 && params[0] == 5 {
+		return termColor(params[1]), 2
+	} else if len(params) >= 4 && params[0] == 2 {
+		return fmt.Sprintf("#%02x%02x%02x", params[1]&0xff, params[2]&0xff, params[3]&0xff), 4
+	}
+
+	return "", len(params)
+}
+
+// Apply SGR (Select Graphic Rendition) parameters of "ESC [ ... m" sequence
+func (ts *termStyle) applySGR(params []int) {
+	if len(params) == 0 {
+		params = []int{0}
+	}
+
+	for i := 0; i < len(params); i++ {
+		switch p := params[i]; {
+		case p == 0:
+			*ts = termStyle{}
+		case p == 1:
+			ts.setAttr('b', true)
+		case p == 2:
+			ts.setAttr('d', true)
+		case p == 3:
+			ts.setAttr('i', true)
+		case p == 4:
+			ts.setAttr('u', true)
+		case p == 5 || p == 6:
+			ts.setAttr('l', true)
+		case p == 7:
+			ts.setAttr('r', true)
+		case p == 22:
+			ts.setAttr('b', false)
+			ts.setAttr('d', false)
+		case p == 23:
+			ts.setAttr('i', false)
+		case p == 24:
+			ts.setAttr('u', false)
+		case p == 25:
+			ts.setAttr('l', false)
+		case p == 27:
+			ts.setAttr('r', false)
+		case p >= 30 && p <= 37:
+			ts.fg = termColor(p - 30)
+		case p == 38:
+			color, n := termExtColor(params[i+1:])
+			ts.fg = color
+			i += n
+		case p == 39:
+			ts.fg = ""
+		case p >= 40 && p <= 47:
+			ts.bg = termColor(p - 40)
+		case p == 48:
+			color, n := termExtColor(params[i+1:])
+			ts.bg = color
+			i += n
+		case p == 49:
+			ts.bg = ""
+		case p >= 90 && p <= 97:
+			ts.fg = termColor(p - 90 + 8)
+		case p >= 100 && p <= 107:
+			ts.bg = termColor(p - 100 + 8)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package teawidgets
+
+import (
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/isbm/crtview"
+)
+
+/*
+TeaTerminalView is a text view of a terminal. While the input is attached,
+all the keys are translated to the terminal sequences and sent to the input,
+as if they were typed on a keyboard. Otherwise it is a usual text view.
+*/
+type TeaTerminalView struct {
+	input func(data []byte)
+	mtx   sync.Mutex
+
+	*crtview.TextView
+}
+
+// NewTeaTerminalView constructor
+func NewTeaTerminalView() *TeaTerminalView {
+	return &TeaTerminalView{
+		TextView: crtview.NewTextView(),
+	}
+}
+
+// SetInput attaches the input, receiving the keys. Nil detaches it.
+func (tv *TeaTerminalView) SetInput(input func(data []byte)) {
+	tv.mtx.Lock()
+	defer tv.mtx.Unlock()
+
+	tv.input = input
+}
+
+// IsGrabbingKeys returns true, if all the keys are sent to the input
+func (tv *TeaTerminalView) IsGrabbingKeys() bool {
+	tv.mtx.Lock()
+	defer tv.mtx.Unlock()
+
+	return tv.input != nil
+}
+
+// InputHandler sends the keys to the input while it is attached
+func (tv *TeaTerminalView) InputHandler() func(event *tcell.EventKey, setFocus func(p crtview.Primitive)) {
+	handler := tv.TextView.InputHandler()
+	return func(event *tcell.EventKey, setFocus func(p crtview.Primitive)) {
+		tv.mtx.Lock()
+		input := tv.input
+		tv.mtx.Unlock()
+
+		if input == nil {
+			handler(event, setFocus)
+		} else if data := termKeyBytes(event); len(data) > 0 {
+			input(data)
+		}
+	}
+}
+
+// Sequences of the special keys, as xterm sends them
+var termKeySequences = map[tcell.Key]string{
+	tcell.KeyEnter:      "\r",
+	tcell.KeyTab:        "\t",
+	tcell.KeyBacktab:    "\x1b[Z",
+	tcell.KeyBackspace:  "\x7f",
+	tcell.KeyBackspace2: "\x7f",
+	tcell.KeyEscape:     "\x1b",
+	tcell.KeyUp:         "\x1b[A",
+	tcell.KeyDown:       "\x1b[B",
+	tcell.KeyRight:      "\x1b[C",
+	tcell.KeyLeft:       "\x1b[D",
+	tcell.KeyHome:       "\x1b[H",
+	tcell.KeyEnd:        "\x1b[F",
+	tcell.KeyInsert:     "\x1b[2~",
+	tcell.KeyDelete:     "\x1b[3~",
+	tcell.KeyPgUp:       "\x1b[5~",
+	tcell.KeyPgDn:       "\x1b[6~",
+	tcell.KeyF1:         "\x1bOP",
+	tcell.KeyF2:         "\x1bOQ",
+	tcell.KeyF3:         "\x1bOR",
+	tcell.KeyF4:         "\x1bOS",
+	tcell.KeyF5:         "\x1b[15~",
+	tcell.KeyF6:         "\x1b[17~",
+	tcell.KeyF7:         "\x1b[18~",
+	tcell.KeyF8:         "\x1b[19~",
+	tcell.KeyF9:         "\x1b[20~",
+	tcell.KeyF10:        "\x1b[21~",
+	tcell.KeyF11:        "\x1b[23~",
+	tcell.KeyF12:        "\x1b[24~",
+}
+
+// Translate the key to the bytes, a terminal sends to the program. Alt is sent as ESC prefix.
+func termKeyBytes(event *tcell.EventKey) []byte {
+	var data string
+	if event.Key() == tcell.KeyRune {
+		data = string(event.Rune())
+	} else if seq, ok := termKeySequences[event.Key()]; ok {
+		data = seq
+	} else if event.Key() <= tcell.KeyUS {
+		data = string(rune(event.Key())) // Control keys, such as Ctrl+C or Ctrl+D
+	}
+
+	if data != "" && event.Modifiers()&tcell.ModAlt != 0 {
+		data = "\x1b" + data
+	}
+
+	return []byte(data)
+}
@@ -0,0 +1,448 @@
+package teawidgets
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/isbm/crtview"
+	"gitlab.com/isbm/teabox"
+	"gitlab.com/isbm/teabox/teaboxlib"
+)
+
+// Character on the terminal screen
+type termCell struct {
+	r     rune
+	style termStyle
+}
+
+/*
+TeaTerminalWriter is a terminal-aware writer in front of a text view. It keeps a screen
+of the size of the pseudo-terminal, so the output of the tools, which are moving the cursor
+around (progress bars, spinners, "\r" updates etc), looks the same way as in a terminal.
+The lines, scrolled off the screen, are kept above it in the text view.
+
+Supported are carriage return, backspace, tabs, cursor movement, erasing of the line
+and the screen, and SGR colours. Other escape sequences are silently dropped.
+*/
+type TeaTerminalWriter struct {
+	w          *crtview.TextView
+	bg         string          // Background of the text view, as a colour tag
+	scrollback strings.Builder // Rendered lines, already scrolled off the screen
+	lines      int             // Lines in the scrollback, which is kept up to the LOGGER_MAX_LINES
+	screen     [][]termCell
+	rows       int
+	cols       int
+	row        int
+	col        int
+	savedRow   int
+	savedCol   int
+	style      termStyle
+	pending    []byte // Incomplete escape sequence or UTF-8 character, left from the previous write
+	cursor     bool   // Cursor is shown, if the program is not hiding it
+	hidden     bool
+	mtx        sync.Mutex
+}
+
+// NewTeaTerminalWriter constructor. The current content of the text view is kept above the output.
+func NewTeaTerminalWriter(w *crtview.TextView, rows, cols int) *TeaTerminalWriter {
+	tw := &TeaTerminalWriter{w: w, bg: termColorName(w.GetBackgroundColor())}
+	if text := w.GetText(false); text != "" {
+		tw.scrollback.WriteString(text)
+		if !strings.HasSuffix(text, "\n") {
+			tw.scrollback.WriteString("\n")
+		}
+		tw.lines = strings.Count(tw.scrollback.String(), "\n")
+	}
+	tw.SetSize(rows, cols)
+
+	return tw
+}
+
+// SetSize of the screen. Returns true if the size was changed.
+func (tw *TeaTerminalWriter) SetSize(rows, cols int) bool {
+	tw.mtx.Lock()
+	defer tw.mtx.Unlock()
+
+	if rows < 1 {
+		rows = 24
+	}
+	if cols < 1 {
+		cols = 80
+	}
+
+	if rows == tw.rows && cols == tw.cols {
+		return false
+	}
+
+	tw.rows, tw.cols = rows, cols
+	for len(tw.screen) > tw.rows {
+		tw.scrollUp()
+	}
+	tw.row = tw.clamp(tw.row, 0, tw.rows-1)
+	tw.col = tw.clamp(tw.col, 0, tw.cols-1)
+
+	return true
+}
+
+// SetCursorVisible shows the cursor as a reversed cell, so the user sees where the input goes.
+// Programs can still hide it with "ESC [ ? 25 l".
+func (tw *TeaTerminalWriter) SetCursorVisible(visible bool) {
+	tw.mtx.Lock()
+	defer tw.mtx.Unlock()
+
+	tw.cursor = visible
+}
+
+// GetSize of the screen as rows and columns
+func (tw *TeaTerminalWriter) GetSize() (int, int) {
+	tw.mtx.Lock()
+	defer tw.mtx.Unlock()
+
+	return tw.rows, tw.cols
+}
+
+// GetLastLines returns up to "n" last non-empty lines of the screen as a plain text
+func (tw *TeaTerminalWriter) GetLastLines(n int) string {
+	tw.mtx.Lock()
+	defer tw.mtx.Unlock()
+
+	lines := []string{}
+	for idx := len(tw.screen) - 1; idx >= 0 && len(lines) < n; idx-- {
+		var line strings.Builder
+		for _, cell := range tw.screen[idx] {
+			if cell.r == 0 {
+				line.WriteRune(' ')
+			} else {
+				line.WriteRune(cell.r)
+			}
+		}
+		if text := strings.TrimSpace(line.String()); text != "" {
+			lines = append([]string{text}, lines...)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Write the output of the command and update the text view
+func (tw *TeaTerminalWriter) Write(p []byte) (int, error) {
+	tw.mtx.Lock()
+	tw.parse(append(tw.pending, p...))
+	text := tw.render()
+	tw.mtx.Unlock()
+
+	tw.w.SetText(text)
+	teabox.GetTeaboxApp().Draw()
+
+	return len(p), nil
+}
+
+func (tw *TeaTerminalWriter) clamp(v, min, max int) int {
+	if v < min {
+		return min
+	} else if v > max {
+		return max
+	}
+	return v
+}
+
+// Get the line of the screen at the row, adding missing lines
+func (tw *TeaTerminalWriter) line(row int) []termCell {
+	for len(tw.screen) <= row {
+		tw.screen = append(tw.screen, []termCell{})
+	}
+	return tw.screen[row]
+}
+
+// Move the top line of the screen to the scrollback
+func (tw *TeaTerminalWriter) scrollUp() {
+	if len(tw.screen) == 0 {
+		return
+	}
+	tw.scrollback.WriteString(tw.renderLine(tw.screen[0]))
+	tw.scrollback.WriteString("\n")
+	tw.screen = tw.screen[1:]
+
+	// Oldest lines are dropped, but not on every line, as this copies the whole scrollback
+	tw.lines++
+	if max := teaboxlib.LOGGER_MAX_LINES; max > 0 && tw.lines > max+max/10 {
+		var scrollback string
+		scrollback, tw.lines = logTrimLines(tw.scrollback.String(), tw.lines, max)
+		tw.scrollback.Reset()
+		tw.scrollback.WriteString(scrollback)
+	}
+}
+
+func (tw *TeaTerminalWriter) lineFeed() {
+	tw.row++
+	if tw.row >= tw.rows {
+		tw.scrollUp()
+		tw.row = tw.rows - 1
+	}
+	tw.line(tw.row)
+}
+
+// Put a printable character at the cursor
+func (tw *TeaTerminalWriter) put(r rune) {
+	if tw.col >= tw.cols {
+		tw.col = 0
+		tw.lineFeed()
+	}
+
+	line := tw.line(tw.row)
+	for len(line) <= tw.col {
+		line = append(line, termCell{})
+	}
+	line[tw.col] = termCell{r: r, style: tw.style}
+	tw.screen[tw.row] = line
+	tw.col++
+}
+
+// Erase characters of the current line in the range [from, to)
+func (tw *TeaTerminalWriter) erase(from, to int) {
+	line := tw.line(tw.row)
+	if to > len(line) {
+		to = len(line)
+	}
+	for idx := from; idx < to; idx++ {
+		line[idx] = termCell{}
+	}
+}
+
+// Parse the output. Incomplete trailing sequence is kept until the next write.
+func (tw *TeaTerminalWriter) parse(data []byte) {
+	tw.pending = nil
+	for i := 0; i < len(data); {
+		b := data[i]
+		switch {
+		case b == 0x1b:
+			n := tw.escape(data[i:])
+			if n == 0 {
+				tw.pending = append([]byte{}, data[i:]...)
+				return
+			}
+			i += n
+		case b < 0x20 || b == 0x7f:
+			tw.control(b)
+			i++
+		default:
+			if !utf8.FullRune(data[i:]) {
+				tw.pending = append([]byte{}, data[i:]...)
+				return
+			}
+			r, size := utf8.DecodeRune(data[i:])
+			tw.put(r)
+			i += size
+		}
+	}
+}
+
+// Handle a control character
+func (tw *TeaTerminalWriter) control(b byte) {
+	switch b {
+	case '\r':
+		tw.col = 0
+	case '\n', '\v', '\f':
+		tw.lineFeed()
+	case '\b':
+		if tw.col > 0 {
+			tw.col--
+		}
+	case '\t':
+		tw.col = tw.clamp((tw.col/8+1)*8, 0, tw.cols-1)
+	}
+}
+
+// Handle an escape sequence, returning the number of consumed bytes, or 0 if it is incomplete
+func (tw *TeaTerminalWriter) escape(seq []byte) int {
+	n := termEscapeLen(seq)
+	if n == 0 {
+		return 0
+	}
+
+	switch seq[1] {
+	case '[': // CSI, unless it is broken
+		if n > 2 {
+			tw.csi(seq[n-1], string(seq[2:n-1]))
+		}
+	case '7':
+		tw.savedRow, tw.savedCol = tw.row, tw.col
+	case '8':
+		tw.row, tw.col = tw.savedRow, tw.savedCol
+	case 'D':
+		tw.lineFeed()
+	case 'E':
+		tw.col = 0
+		tw.lineFeed()
+	case 'M':
+		if tw.row > 0 {
+			tw.row--
+		}
+	case 'c':
+		tw.screen = nil
+		tw.row, tw.col = 0, 0
+		tw.style = termStyle{}
+	}
+
+	return n
+}
+
+// Handle a control sequence "ESC [ params final"
+func (tw *TeaTerminalWriter) csi(final byte, params string) {
+	if params == "?25" && (final == 'h' || final == 'l') {
+		tw.hidden = final == 'l'
+		return
+	}
+
+	args, ok := termCSIArgs(params)
+	if !ok {
+		return // Other private modes, such as alternate screen
+	}
+
+	// Parameter at the position, or the default if it is omitted or zero
+	arg := func(idx, def int) int {
+		if idx < len(args) && args[idx] > 0 {
+			return args[idx]
+		}
+		return def
+	}
+
+	switch final {
+	case 'A':
+		tw.row = tw.clamp(tw.row-arg(0, 1), 0, tw.rows-1)
+	case 'B', 'e':
+		tw.row = tw.clamp(tw.row+arg(0, 1), 0, tw.rows-1)
+	case 'C', 'a':
+		tw.col = tw.clamp(tw.col+arg(0, 1), 0, tw.cols-1)
+	case 'D':
+		tw.col = tw.clamp(tw.col-arg(0, 1), 0, tw.cols-1)
+	case 'E':
+		tw.row = tw.clamp(tw.row+arg(0, 1), 0, tw.rows-1)
+		tw.col = 0
+	case 'F':
+		tw.row = tw.clamp(tw.row-arg(0, 1), 0, tw.rows-1)
+		tw.col = 0
+	case 'G', '`':
+		tw.col = tw.clamp(arg(0, 1)-1, 0, tw.cols-1)
+	case 'd':
+		tw.row = tw.clamp(arg(0, 1)-1, 0, tw.rows-1)
+	case 'H', 'f':
+		tw.row = tw.clamp(arg(0, 1)-1, 0, tw.rows-1)
+		tw.col = tw.clamp(arg(1, 1)-1, 0, tw.cols-1)
+	case 'J':
+		switch arg(0, 0) {
+		case 0: // Below the cursor
+			tw.erase(tw.col, tw.cols)
+			if tw.row+1 < len(tw.screen) {
+				tw.screen = tw.screen[:tw.row+1]
+			}
+		case 1: // Above the cursor
+			for idx := 0; idx < tw.row && idx < len(tw.screen); idx++ {
+				tw.screen[idx] = []termCell{}
+			}
+			tw.erase(0, tw.col+1)
+		default: // Whole screen. The output is kept in the scrollback, as this is a log.
+			for len(tw.screen) > 0 {
+				tw.scrollUp()
+			}
+		}
+	case 'K':
+		switch arg(0, 0) {
+		case 0:
+			tw.erase(tw.col, tw.cols)
+		case 1:
+			tw.erase(0, tw.col+1)
+		default:
+			tw.erase(0, tw.cols)
+		}
+	case 'X':
+		tw.erase(tw.col, tw.col+arg(0, 1))
+	case 'P': // Delete characters, shifting the rest of the line left
+		line := tw.line(tw.row)
+		if tw.col < len(line) {
+			n := tw.clamp(arg(0, 1), 0, len(line)-tw.col)
+			tw.screen[tw.row] = append(line[:tw.col], line[tw.col+n:]...)
+		}
+	case '@': // Insert blanks, shifting the rest of the line right
+		line := tw.line(tw.row)
+		if tw.col < len(line) {
+			blanks := make([]termCell, arg(0, 1))
+			line = append(line[:tw.col], append(blanks, line[tw.col:]...)...)
+			if len(line) > tw.cols {
+				line = line[:tw.cols]
+			}
+			tw.screen[tw.row] = line
+		}
+	case 'm':
+		tw.style.applySGR(args)
+	case 's':
+		tw.savedRow, tw.savedCol = tw.row, tw.col
+	case 'u':
+		tw.row, tw.col = tw.savedRow, tw.savedCol
+	}
+}
+
+// Render a line of the screen with crtview colour tags
+func (tw *TeaTerminalWriter) renderLine(line []termCell) string {
+	// Trailing blanks are not rendered
+	end := len(line)
+	for end > 0 && (line[end-1].r == 0 || line[end-1].r == ' ') && line[end-1].style.bg == "" && !strings.Contains(line[end-1].style.attrs, "r") {
+		end--
+	}
+
+	var out, segment strings.Builder
+	style := termStyle{}
+	for _, cell := range line[:end] {
+		if cell.style != style {
+			out.WriteString(crtview.Escape(segment.String()))
+			segment.Reset()
+			out.WriteString(cell.style.tag(tw.bg))
+			style = cell.style
+		}
+		if cell.r == 0 {
+			segment.WriteRune(' ')
+		} else {
+			segment.WriteRune(cell.r)
+		}
+	}
+	out.WriteString(crtview.Escape(segment.String()))
+	if style != (termStyle{}) {
+		out.WriteString(termStyle{}.tag(tw.bg))
+	}
+
+	return out.String()
+}
+
+// Copy of the line with the cell under the cursor reversed
+func (tw *TeaTerminalWriter) cursorLine(line []termCell) []termCell {
+	col := tw.clamp(tw.col, 0, tw.cols-1)
+	out := make([]termCell, col+1)
+	copy(out, line)
+	if len(line) > len(out) {
+		out = append(out, line[len(out):]...)
+	}
+	out[col].style.setAttr('r', !strings.Contains(out[col].style.attrs, "r"))
+
+	return out
+}
+
+// Render the scrollback and the screen
+func (tw *TeaTerminalWriter) render() string {
+	var out strings.Builder
+	out.WriteString(tw.scrollback.String())
+	if tw.cursor && !tw.hidden {
+		tw.line(tw.row)
+	}
+	for idx, line := range tw.screen {
+		if idx > 0 {
+			out.WriteString("\n")
+		}
+		if idx == tw.row && tw.cursor && !tw.hidden {
+			line = tw.cursorLine(line)
+		}
+		out.WriteString(tw.renderLine(line))
+	}
+
+	return out.String()
+}
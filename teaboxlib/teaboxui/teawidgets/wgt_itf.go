@@ -1,6 +1,8 @@
 package teawidgets
 
 import (
+	"errors"
+
 	"github.com/isbm/crtview"
 	"gitlab.com/isbm/teabox/teaboxlib"
 )
@@ -9,11 +11,50 @@ const (
 	LANDING_WINDOW_LOGGER   = "_logger"
 	LANDING_WINDOW_PROGRESS = "_progress"
 	LANDING_WINDOW_LIST     = "_list"
+	LANDING_WINDOW_TERMINAL = "_terminal"
+
+	INTRO_WINDOW_COMMON   = "_intro-common"
+	LOAD_WINDOW_COMMON    = "_load-module-common"
+	HISTORY_WINDOW_COMMON = "_history-common"
+	JOBS_WINDOW_COMMON    = "_jobs-common"
+)
 
-	INTRO_WINDOW_COMMON = "_intro-common"
-	LOAD_WINDOW_COMMON  = "_load-module-common"
+// States of a command within a chain, as reported on a landing page
+const (
+	STEP_RUNNING = iota
+	STEP_DONE
+	STEP_SKIPPED
+	STEP_FAILED
+	STEP_CANCELLED
 )
 
+// ErrLanderCancelled is returned by a landing window Action, if the command was cancelled by the user
+var ErrLanderCancelled = errors.New("cancelled")
+
+// ErrLanderTimeout is returned by a landing window Action, if the command has exceeded its timeout
+var ErrLanderTimeout = errors.New("timed out")
+
+// LanderExitError is returned by a landing window Action, if the command exits with non-zero code.
+// The error message is the output of the command, explaining the failure.
+type LanderExitError struct {
+	code    int
+	message string
+}
+
+func (e *LanderExitError) Error() string {
+	return e.message
+}
+
+// GetExitCode returns the exit code of the command
+func (e *LanderExitError) GetExitCode() int {
+	return e.code
+}
+
+// Errors of the lander itself rather than of the command, those are returned by the Action as is
+func isLanderStop(err error) bool {
+	return errors.Is(err, ErrLanderCancelled) || errors.Is(err, ErrLanderTimeout)
+}
+
 // TeaboxLandingWindow interface.
 // Window widgets are the entire panels, that can use the whole window space.
 type TeaboxLandingWindow interface {
@@ -21,15 +62,44 @@ type TeaboxLandingWindow interface {
 	AsWidgetPrimitive() crtview.Primitive
 
 	// Action implementation, which takes:
-	//   - "callback" path of the Unix socket to start a listener on it
-	//   - "cmdpath" is a command absolute path (preferrably) or env returned
-	//   - "cmdargs" are the arguments to the "cmdpath"
-	Action(cmdpath string, cmdargs ...string) error
-	StopListener() error
+	//   - "modcmd" is a module command with its absolute path (preferrably), working directory,
+	//     environment and timeout
+	//   - "cmdargs" are the arguments to the command
+	Action(modcmd *teaboxlib.TeaConfModCommand, cmdargs ...string) error
+
+	// Set the environment of the run, such as its socket, which is added to every command
+	SetEnv(env []string)
+
+	// Set the functions, which tell if the run is shown to the user, and wait until it is,
+	// as the user is needed. The waiting returns false, if the run was cancelled meanwhile.
+	SetForegroundActions(isForeground, waitForeground func() bool)
+
+	// Cancel the running command, so its Action returns ErrLanderCancelled
+	Cancel()
 
 	// Return window action on Unix socket calls, specific per this widget
 	GetWindowAction() func(call *teaboxlib.TeaboxAPICall) string
 
+	// Set a state of a command in a chain, so the landing page shows
+	// which steps ran, which were skipped and which failed.
+	SetStepState(idx int, title string, state int)
+
 	// Reset all the values to the initial state
 	Reset()
 }
+
+// TeaboxKeyGrabber is a widget, which takes all the keys while it is focused,
+// including those the application uses for itself, such as arrows or Ctrl+C.
+type TeaboxKeyGrabber interface {
+	crtview.Primitive
+
+	// Returns true, while the widget takes all the keys
+	IsGrabbingKeys() bool
+}
+
+// TeaboxTranscriptHolder is a landing window, which keeps the whole output of the commands,
+// so it can be viewed after the run.
+type TeaboxTranscriptHolder interface {
+	// Returns the output of all commands since the last reset, with the colour tags
+	GetTranscript() string
+}
@@ -21,13 +21,16 @@ TeaConf is a global config of everything.
 */
 
 type TeaConf struct {
-	title              string
-	contentPath        string
-	initConfPath       string
-	callbackSocketPath string
-	rootConf           *nanoconf.Config
+	title        string
+	contentPath  string
+	initConfPath string
+	authPolicy   string
+	strictAPI    bool
+	rootConf     *nanoconf.Config
+	env          map[string]string // Global environment of every command
 
 	modIndex []TeaConfComponent
+	errors   []*TeaConfError
 	wzlib_logger.WzLogger
 }
 
@@ -45,13 +48,21 @@ func NewTeaConf(appname string) (*TeaConf, error) {
 
 	tc.rootConf = nanoconf.NewConfig(configPath)
 	tc.contentPath = tc.GetRootConfig().Root().String("content", "")
-	tc.callbackSocketPath = tc.GetRootConfig().Root().String("callback", "")
+	tc.authPolicy = tc.GetRootConfig().Root().String("auth", "")
+	if tc.authPolicy == "" {
+		tc.authPolicy = AUTH_USER
+	}
+	if !IsAuthPolicy(tc.authPolicy) {
+		return nil, fmt.Errorf("unknown authentication policy \"%s\" in %s", tc.authPolicy, configPath)
+	}
+	tc.strictAPI, _ = tc.GetRootConfig().Root().Raw()["strict-api"].(bool)
 	tc.initConfPath = path.Join(tc.contentPath, "init.conf")
 
+	tc.env = map[string]string{}
 	environ, exists := tc.GetRootConfig().Root().Raw()["env"]
 	if exists {
 		for envKey, envVar := range environ.(map[interface{}]interface{}) {
-			os.Setenv(fmt.Sprintf("%v", envKey), fmt.Sprintf("%v", envVar))
+			tc.env[fmt.Sprintf("%v", envKey)] = fmt.Sprintf("%v", envVar)
 		}
 	}
 
@@ -68,18 +79,34 @@ func (tc *TeaConf) GetContentPath() string {
 	return tc.contentPath
 }
 
+// GetEnv returns the global environment, which is added to every command of the modules
+func (tc *TeaConf) GetEnv() map[string]string {
+	return tc.env
+}
+
 func (tc *TeaConf) GetTitle() string {
 	return tc.title
 }
 
-func (tc *TeaConf) GetSocketPath() string {
-	return tc.callbackSocketPath
+// GetAuthPolicy returns the authentication policy of the socket clients, one of AUTH_*
+func (tc *TeaConf) GetAuthPolicy() string {
+	return tc.authPolicy
+}
+
+// IsStrictAPI returns true, if every API call, which could not be done, should be logged
+func (tc *TeaConf) IsStrictAPI() bool {
+	return tc.strictAPI
 }
 
 func (tc *TeaConf) GetModuleStructure() []TeaConfComponent {
 	return tc.modIndex
 }
 
+// GetErrors returns configuration errors of all modules, collected during InitConfig, including the missing files.
+func (tc *TeaConf) GetErrors() []*TeaConfError {
+	return tc.errors
+}
+
 // Returns a group by its ID
 func (tc *TeaConf) getGroup(id string) TeaConfComponent {
 	for _, c := range tc.modIndex {
@@ -99,6 +126,7 @@ func (tc *TeaConf) InitConfig() error {
 	}
 
 	tc.modIndex = []TeaConfComponent{}
+	tc.errors = []*TeaConfError{}
 
 	for _, p := range []string{tc.initConfPath, tc.contentPath} {
 		_, err := os.Stat(p)
@@ -118,30 +146,12 @@ func (tc *TeaConf) InitConfig() error {
 			}
 
 			if path.Base(pth) == "init.conf" {
-				defer func() {
-					if err := recover(); err != nil {
-						tc.GetLogger().Errorf("Error loading %s: %s", pth, err)
-						panic(err)
-					}
-				}()
-				c := nanoconf.NewConfig(pth)
-				title := c.Root().String("title", "")
-				if title == "" {
-					fmt.Printf("Skipping module: %s (insufficient configuration)\n", pth)
+				m, groupId := tc.loadModule(pth)
+				if m == nil {
 					return nil
 				}
+				tc.errors = append(append(tc.errors, m.GetErrors()...), m.GetFileErrors()...)
 
-				m := NewTeaConfModule(title)
-				m.modulePath = path.Dir(pth)
-				if c.Root().Raw()["commands"] != nil {
-					m.SetCondition(c.Root().Raw()["conditions"]).
-						SetCommands(c.Root().Raw()["commands"]).
-						SetCallbackPath(tc.GetSocketPath()).
-						SetLandingPageType(c.Root().String("landing", "")).
-						SetSetupCommand(c.Root().String("setup", ""))
-				}
-
-				groupId := c.Root().String("group", "")
 				if groupId != "" { // Belongs to group and IS a group. Group has no commands, otherwise it is a module that only belongs to a group
 					tc.getGroup(groupId).Add(m)
 				} else {
@@ -153,12 +163,55 @@ func (tc *TeaConf) InitConfig() error {
 		Unsorted: true,
 	})
 
-	// Sort all the items, except "Exit", which should be always at the end.
+	// Sort all the items, except "History", "Shell" and "Exit", which should be always at the end.
 	sort.Slice(tc.modIndex, func(i, j int) bool {
 		return tc.modIndex[i].GetTitle() < tc.modIndex[j].GetTitle()
 	})
 
+	if HISTORY_SIZE > 0 {
+		tc.modIndex = append(tc.modIndex, NewTeaConfCmd("history", LABEL_HISTORY))
+	}
+	if SYSTEM_SHELL != "" {
+		tc.modIndex = append(tc.modIndex, NewTeaConfCmd("shell", LABEL_SHELL))
+	}
 	tc.modIndex = append(tc.modIndex, NewTeaConfCmd("exit", LABEL_EXIT))
 
 	return err
 }
+
+// Load a module from its "init.conf" and return it with its group ID, if any.
+// Broken configuration does not stop loading, but is kept within the module,
+// so it is still displayed, but cannot run.
+func (tc *TeaConf) loadModule(pth string) (m *TeaConfModule, groupId string) {
+	defer func() {
+		if err := recover(); err != nil {
+			// YAML syntax is broken, so the title is unknown at this point
+			m = NewTeaConfModule(path.Base(path.Dir(pth)))
+			m.modulePath = path.Dir(pth)
+			m.SetLandingPageType("")
+			m.addError("", fmt.Errorf("%v", err))
+			groupId = ""
+		}
+	}()
+
+	c := nanoconf.NewConfig(pth)
+	title := c.Root().String("title", "")
+	if title == "" {
+		// Without the title the module cannot be displayed, so it is skipped and only its error is kept
+		tc.errors = append(tc.errors, NewTeaConfError(pth, "", "title", "module has no title"))
+		return nil, ""
+	}
+
+	m = NewTeaConfModule(title)
+	m.modulePath = path.Dir(pth)
+	if c.Root().Raw()["commands"] != nil {
+		// Landing page goes before the commands, as they are checked against it
+		m.SetGlobalEnv(tc.env).
+			SetCondition(c.Root().Raw()["conditions"]).
+			SetLandingPageType(c.Root().String("landing", "")).
+			SetCommands(c.Root().Raw()["commands"]).
+			SetSetupCommand(c.Root().String("setup", ""))
+	}
+
+	return m, c.Root().String("group", "")
+}
@@ -34,8 +34,20 @@ type TeaConfArgAttributes struct {
 	opt []string
 }
 
+// Keywords of the tabular widget, those values should be integers
+var intAttributes = []string{"height", "expand", "value", "hidden"}
+
+func isIntAttribute(kw string) bool {
+	for _, ikw := range intAttributes {
+		if ikw == kw {
+			return true
+		}
+	}
+	return false
+}
+
 // NewTeaConfArgAttributes constructor
-func NewTeaConfArgAttributes(attr []interface{}) *TeaConfArgAttributes {
+func NewTeaConfArgAttributes(attr []interface{}) (*TeaConfArgAttributes, error) {
 	if attr == nil {
 		attr = []interface{}{}
 	}
@@ -47,32 +59,42 @@ func NewTeaConfArgAttributes(attr []interface{}) *TeaConfArgAttributes {
 }
 
 // Parse the attributes
-func (tca *TeaConfArgAttributes) parse(attrs []interface{}) *TeaConfArgAttributes {
-	for _, rAttr := range attrs {
+func (tca *TeaConfArgAttributes) parse(attrs []interface{}) (*TeaConfArgAttributes, error) {
+	errs := TeaConfErrors{}
+	for idx, rAttr := range attrs {
 		attr, ok := rAttr.(string)
 		if !ok {
+			errs.add(fmt.Sprintf("%d", idx), fmt.Errorf("attribute %v should be a string", rAttr))
 			continue
 		}
 
 		if strings.Contains(attr, "=") {
-			tca.addKwArg(attr)
+			errs.add(fmt.Sprintf("%d", idx), tca.addKwArg(attr))
 		} else {
 			tca.opt = append(tca.opt, attr)
 		}
 	}
 
-	return tca
+	if err := errs.get(); err != nil {
+		return nil, err
+	}
+
+	return tca, nil
 }
 
-func (tca *TeaConfArgAttributes) addKwArg(kwa string) {
+func (tca *TeaConfArgAttributes) addKwArg(kwa string) error {
 	args := strings.SplitN(kwa, "=", 2)
-	if len(args) != 2 {
-		panic(fmt.Sprintf("Wrong keyword argument: %s", kwa))
+	if len(args) != 2 || strings.TrimSpace(args[0]) == "" || strings.TrimSpace(args[1]) == "" {
+		return fmt.Errorf("wrong keyword argument: %s", kwa)
 	}
 
 	// Parse lists
 	for _, key := range strings.Split(strings.ReplaceAll(strings.TrimSpace(args[0]), " ", ""), ",") {
 		for _, attr := range strings.Split(args[1], ",") {
+			if _, err := strconv.Atoi(strings.TrimSpace(attr)); err != nil && isIntAttribute(key) {
+				return fmt.Errorf("attribute \"%s\" expects an integer, not \"%s\"", key, strings.TrimSpace(attr))
+			}
+
 			_, exist := tca.kwa[key]
 			if !exist {
 				tca.kwa[key] = []string{}
@@ -80,6 +102,8 @@ func (tca *TeaConfArgAttributes) addKwArg(kwa string) {
 			tca.kwa[key] = append(tca.kwa[key], strings.TrimSpace(attr))
 		}
 	}
+
+	return nil
 }
 
 // KeywordHasAllAttrs checks if a keyword has all the following attributes
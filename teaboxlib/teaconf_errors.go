@@ -0,0 +1,135 @@
+package teaboxlib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TeaConfError is a problem in a module configuration. It points to the file,
+// the module title, the command title and the key where the problem was found.
+type TeaConfError struct {
+	file    string
+	module  string
+	command string
+	key     string
+	message string
+}
+
+// NewTeaConfError constructor
+func NewTeaConfError(file, module, key, message string) *TeaConfError {
+	return &TeaConfError{
+		file:    file,
+		module:  module,
+		key:     key,
+		message: message,
+	}
+}
+
+// Constructor for the parsers, those know only a field, but not the file or module yet.
+func newTeaConfFieldError(key, format string, a ...interface{}) *TeaConfError {
+	return NewTeaConfError("", "", key, fmt.Sprintf(format, a...))
+}
+
+// Turn any error into a configuration error at given key. If the error is already
+// a configuration error, then the key is prepended to its field path.
+func asTeaConfError(key string, err error) *TeaConfError {
+	tce, ok := err.(*TeaConfError)
+	if !ok {
+		return newTeaConfFieldError(key, "%s", err.Error())
+	}
+
+	if key != "" {
+		if tce.key != "" {
+			tce.key = key + ":" + tce.key
+		} else {
+			tce.key = key
+		}
+	}
+
+	return tce
+}
+
+// Turn any error into configuration errors at given key. All errors are taken from TeaConfErrors.
+func asTeaConfErrors(key string, err error) []*TeaConfError {
+	tces, ok := err.(TeaConfErrors)
+	if !ok {
+		return []*TeaConfError{asTeaConfError(key, err)}
+	}
+
+	out := []*TeaConfError{}
+	for _, tce := range tces {
+		out = append(out, asTeaConfError(key, tce))
+	}
+	return out
+}
+
+// TeaConfErrors are all problems, which a parser has found. Parsers do not stop on the first problem,
+// so all of them are reported at once.
+type TeaConfErrors []*TeaConfError
+
+// Add an error at given key, if there is any
+func (tces *TeaConfErrors) add(key string, err error) {
+	if err != nil {
+		*tces = append(*tces, asTeaConfErrors(key, err)...)
+	}
+}
+
+// Get nil if there are no errors, the error itself if there is only one, or all of them otherwise
+func (tces TeaConfErrors) get() error {
+	switch len(tces) {
+	case 0:
+		return nil
+	case 1:
+		return tces[0]
+	}
+	return tces
+}
+
+// Error implements error interface
+func (tces TeaConfErrors) Error() string {
+	out := []string{}
+	for _, tce := range tces {
+		out = append(out, strings.TrimSpace(tce.Error()))
+	}
+	return strings.Join(out, "\n")
+}
+
+// GetFile returns a path to the configuration file
+func (tce *TeaConfError) GetFile() string {
+	return tce.file
+}
+
+// GetModule returns a title of the module, if known
+func (tce *TeaConfError) GetModule() string {
+	return tce.module
+}
+
+// GetCommand returns a title of the command within the module, if known
+func (tce *TeaConfError) GetCommand() string {
+	return tce.command
+}
+
+// GetKey returns the offending key, e.g. "commands:0:args:2:type"
+func (tce *TeaConfError) GetKey() string {
+	return tce.key
+}
+
+// GetMessage returns the description of the problem
+func (tce *TeaConfError) GetMessage() string {
+	return tce.message
+}
+
+// Error implements error interface
+func (tce *TeaConfError) Error() string {
+	out := []string{tce.file}
+	if tce.module != "" && tce.command != "" {
+		out = append(out, fmt.Sprintf("[%s / %s]", tce.module, tce.command))
+	} else if tce.module != "" {
+		out = append(out, fmt.Sprintf("[%s]", tce.module))
+	}
+	if tce.key != "" {
+		out = append(out, fmt.Sprintf("%s:", tce.key))
+	}
+
+	return fmt.Sprintf("%s %s", strings.Join(out, " "), tce.message)
+}
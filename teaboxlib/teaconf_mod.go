@@ -3,13 +3,28 @@ package teaboxlib
 import (
 	"fmt"
 	"os"
+	"os/exec"
+	"path"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/davecgh/go-spew/spew"
 	wzlib_logger "github.com/infra-whizz/wzlib/logger"
+	"gitlab.com/isbm/teabox/teaboxlib/teaconditions"
 )
 
+// Widget types, those are supported by the forms
+var widgetTypes = []string{"dropdown", "list", "text", "toggle", "tabular", "password", "masked", "info"}
+
+func isWidgetType(wt string) bool {
+	for _, t := range widgetTypes {
+		if t == wt {
+			return true
+		}
+	}
+	return false
+}
+
 type TeaConfCmdOption struct {
 	label      string
 	optionType string
@@ -18,14 +33,13 @@ type TeaConfCmdOption struct {
 	wzlib_logger.WzLogger
 }
 
-func NewTeaConfCmdOption(data interface{}) *TeaConfCmdOption {
+func NewTeaConfCmdOption(data interface{}) (*TeaConfCmdOption, error) {
 	tcc := new(TeaConfCmdOption)
 	if err := tcc.Parse(data); err != nil {
-		tcc.GetLogger().Error(fmt.Sprintf("Unable to parse command option %v: %s", data, err.Error()))
-		os.Exit(1)
+		return nil, err
 	}
 
-	return tcc
+	return tcc, nil
 }
 
 /*
@@ -135,72 +149,103 @@ type TeaConfModArg struct {
 	wzlib_logger.WzLogger
 }
 
-func NewTeaConfModArg(args map[interface{}]interface{}) *TeaConfModArg {
+func NewTeaConfModArg(args map[interface{}]interface{}) (*TeaConfModArg, error) {
 	a := new(TeaConfModArg)
 	a.options = []*TeaConfCmdOption{}
 
 	optbuf := []interface{}{}
+	errs := TeaConfErrors{}
 
 	for wn, wd := range args {
-		switch wn.(string) {
-		case "type":
-			a.argtype = wd.(string)
-		case "label":
-			a.label = wd.(string)
+		key, ok := wn.(string)
+		if !ok {
+			errs.add(fmt.Sprintf("%v", wn), fmt.Errorf("argument option should be a string type"))
+			continue
+		}
+
+		switch key {
+		case "type", "label", "name":
+			sv, ok := wd.(string)
+			if !ok {
+				errs.add(key, fmt.Errorf("should be a string, not %v", wd))
+				continue
+			}
+
+			switch key {
+			case "type":
+				a.argtype = sv
+			case "label":
+				a.label = sv
+			case "name":
+				a.name = sv // Add as-is. If it is with double-dash, then it is so.
+			}
 		case "options":
 			// Postpone opts parse
-			optbuf = wd.([]interface{})
-		case "name":
-			a.name = wd.(string) // Add as-is. If it is with double-dash, then it is so.
+			if optbuf, ok = wd.([]interface{}); !ok {
+				errs.add(key, fmt.Errorf("options should be a list"))
+			}
 		case "attributes":
-			attrs, _ := wd.([]interface{}) // Avoid explicit cast crash. If syntax is wrong, then just skip it by passing nil.
-			a.attrs = NewTeaConfArgAttributes(attrs)
+			attrs, ok := wd.([]interface{})
+			if !ok && wd != nil {
+				errs.add(key, fmt.Errorf("attributes should be a list"))
+				continue
+			}
+
+			var err error
+			if a.attrs, err = NewTeaConfArgAttributes(attrs); err != nil {
+				errs.add(key, err)
+			}
 		case "signals":
 			sigs, ok := wd.(map[interface{}]interface{})
 			if !ok {
-				a.GetLogger().Error("signals should be key/value syntax")
-				a.GetLogger().Debug(spew.Sdump(wd))
-				os.Exit(1)
-			} else {
-				var err error
-				a.signals, err = NewTeaConfArgSignals(sigs)
-				if err != nil {
-					a.GetLogger().Error(err.Error())
-				}
+				errs.add(key, fmt.Errorf("signals should be key/value syntax"))
+				continue
+			}
+
+			var err error
+			if a.signals, err = NewTeaConfArgSignals(sigs); err != nil {
+				errs.add(key, err)
 			}
 		}
 	}
 
 	// Parse opts
 	if a.argtype != "tabular" {
-		for _, opt := range optbuf {
-			a.options = append(a.options, NewTeaConfCmdOption(opt))
+		for idx, opt := range optbuf {
+			o, err := NewTeaConfCmdOption(opt)
+			if err != nil {
+				errs.add(fmt.Sprintf("options:%d", idx), err)
+				continue
+			}
+			a.options = append(a.options, o)
 		}
 	} else {
-		a.options = NewTeaConfTabularData(optbuf).MakeOptionsData()
+		var err error
+		if a.options, err = NewTeaConfTabularData(optbuf).MakeOptionsData(); err != nil {
+			errs.add("options", err)
+		}
 	}
 
 	if a.argtype == "" {
-		a.GetLogger().Error("No type found for argument.")
-		a.GetLogger().Debug(spew.Sdump(args))
-		os.Exit(1)
-	} else if a.label == "" && a.argtype != "silent" {
-		a.GetLogger().Error("No label found for argument.")
-		a.GetLogger().Debug(spew.Sdump(args))
-		os.Exit(1)
-	} else if len(a.options) == 0 {
-		a.GetLogger().Error("No default options has been found for argument, but the widget is not dynamic.")
-		a.GetLogger().Debug(spew.Sdump(args))
-		os.Exit(1)
-	} else if a.name == "" {
-		if a.argtype != "info" {
-			a.GetLogger().Error("No name found for argument.")
-			a.GetLogger().Debug(spew.Sdump(args))
-			os.Exit(1)
-		}
+		errs.add("type", fmt.Errorf("no type found for argument"))
+	} else if !isWidgetType(a.argtype) {
+		errs.add("type", fmt.Errorf("unknown widget type \"%s\"", a.argtype))
+	}
+	if a.label == "" {
+		errs.add("label", fmt.Errorf("no label found for argument"))
+	}
+	if len(optbuf) == 0 {
+		errs.add("options", fmt.Errorf("no default options has been found for argument, but the widget is not dynamic"))
+	}
+	if a.name == "" && a.argtype != "info" {
+		errs.add("name", fmt.Errorf("no name found for argument"))
 	}
 
-	return a
+	if err := errs.get(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
 }
 
 func (a *TeaConfModArg) GetWidgetType() string {
@@ -214,7 +259,8 @@ func (a *TeaConfModArg) GetWidgetLabel() string {
 // GetAttrs returns argument extra attributes
 func (a *TeaConfModArg) GetAttrs() *TeaConfArgAttributes {
 	if a.attrs == nil {
-		return NewTeaConfArgAttributes(nil)
+		attrs, _ := NewTeaConfArgAttributes(nil)
+		return attrs
 	}
 
 	return a.attrs
@@ -239,72 +285,200 @@ func (a *TeaConfModArg) GetArgName() string {
 	return a.name
 }
 
+// Modes of showing STDERR of a command on the logger landing window
+const (
+	STDERR_INLINE = "inline" // Interleaved with STDOUT
+	STDERR_SPLIT  = "split"  // In a separate pane below STDOUT
+)
+
 // TeaConfModCommand is a command within a module chain. It will call whatever command, specified in its path
 // and passed arguments (TeaConfModArg).
 type TeaConfModCommand struct {
-	path      string
-	title     string
-	option    string // If not empty, then the command is optional and requires a yes/no before proceed.
-	arguments []*TeaConfModArg
-	flags     []string
-}
-
-func NewTeaConfModCommand(cmd map[interface{}]interface{}) *TeaConfModCommand {
+	path        string
+	title       string
+	option      string // If not empty, then the command is optional and requires a yes/no before proceed.
+	cwd         string // Working directory, the module directory by default
+	env         map[string]string
+	globalEnv   map[string]string // Global environment of the configuration, which env is merged over
+	timeout     time.Duration     // Zero means no timeout
+	results     map[int]*TeaConfCmdResult
+	pty         bool   // Run in a pseudo-terminal
+	stderr      string // Where STDERR is shown: inline with STDOUT or in a separate pane
+	interactive bool   // Run on the real terminal, suspending the UI
+	arguments   []*TeaConfModArg
+	flags       []string
+}
+
+func NewTeaConfModCommand(cmd map[interface{}]interface{}) (*TeaConfModCommand, error) {
 	tcm := new(TeaConfModCommand)
-	tcm.parse(cmd)
-	return tcm
+	if err := tcm.parse(cmd); err != nil {
+		return nil, err
+	}
+
+	return tcm, nil
 }
 
-func (tmc *TeaConfModCommand) parse(cmd map[interface{}]interface{}) *TeaConfModCommand {
+func (tmc *TeaConfModCommand) parse(cmd map[interface{}]interface{}) error {
 	tmc.arguments = []*TeaConfModArg{}
 	tmc.flags = []string{}
+	tmc.env = map[string]string{}
+	tmc.results = map[int]*TeaConfCmdResult{}
 
-	var failed string
-	var obj interface{}
+	// Title is needed first to tell in which command the error is
+	tmc.title, _ = cmd["title"].(string)
+
+	errs := TeaConfErrors{}
 	for k, v := range cmd {
 		sk, ok := k.(string)
 		if !ok {
-			panic(fmt.Sprintf("Parse command failure: option %v should be a string type", k))
+			errs.add(fmt.Sprintf("%v", k), fmt.Errorf("option should be a string type"))
+			continue
 		}
 
-		if sv, _ := v.(string); sv != "" {
+		switch sk {
+		case "path", "title", "option", "cwd":
+			sv, ok := v.(string)
+			if !ok || sv == "" {
+				errs.add(sk, fmt.Errorf("should be a non-empty string"))
+				continue
+			}
+
 			switch sk {
 			case "path":
 				tmc.path = sv
-				if tmc.path == "" {
-					failed = "No target executable defined"
-					break
-				}
-			case "title":
-				tmc.title = sv
 			case "option":
 				tmc.option = sv
+			case "cwd":
+				tmc.cwd = sv
+			}
+		case "stderr":
+			switch sv, _ := v.(string); sv {
+			case STDERR_INLINE, STDERR_SPLIT:
+				tmc.stderr = sv
+			default:
+				errs.add(sk, fmt.Errorf("unknown STDERR mode \"%v\", should be inline or split", v))
+			}
+		case "timeout":
+			var err error
+			if tmc.timeout, err = parseTimeout(fmt.Sprintf("%v", v)); err != nil {
+				errs.add(sk, err)
 			}
-		} else if varg, _ := v.([]interface{}); varg != nil {
-			if sk == "flags" {
-				for _, flag := range v.([]interface{}) {
-					tmc.flags = append(tmc.flags, flag.(string))
+		case "pty", "interactive":
+			vbool, ok := v.(bool)
+			if !ok {
+				errs.add(sk, fmt.Errorf("should be true or false"))
+			} else if sk == "pty" {
+				tmc.pty = vbool
+			} else {
+				tmc.interactive = vbool
+			}
+		case "env":
+			venv, ok := v.(map[interface{}]interface{})
+			if !ok {
+				errs.add(sk, fmt.Errorf("env should be key/value syntax"))
+				continue
+			}
+
+			for envKey, envVar := range venv {
+				switch envVar.(type) {
+				case []interface{}, map[interface{}]interface{}, nil:
+					errs.add(fmt.Sprintf("%s:%v", sk, envKey), fmt.Errorf("value should be a scalar"))
+				default:
+					tmc.env[fmt.Sprintf("%v", envKey)] = fmt.Sprintf("%v", envVar)
+				}
+			}
+		case "results":
+			vres, ok := v.(map[interface{}]interface{})
+			if !ok {
+				errs.add(sk, fmt.Errorf("results should be key/value syntax"))
+				continue
+			}
+
+			for icode, ires := range vres {
+				code, ok := icode.(int)
+				if !ok {
+					errs.add(fmt.Sprintf("%s:%v", sk, icode), fmt.Errorf("exit code should be an integer"))
+					continue
 				}
-			} else if sk == "args" {
-				for _, arg := range v.([]interface{}) {
-					tmc.arguments = append(tmc.arguments, NewTeaConfModArg(arg.(map[interface{}]interface{})))
+
+				res, err := NewTeaConfCmdResult(code, ires)
+				if err != nil {
+					errs.add(fmt.Sprintf("%s:%d", sk, code), err)
+					continue
 				}
+				tmc.results[code] = res
+			}
+		case "flags":
+			varg, ok := v.([]interface{})
+			if !ok {
+				errs.add(sk, fmt.Errorf("flags should be a list"))
+				continue
 			}
-		} else {
-			if failed == "" {
-				failed = fmt.Sprintf("%v", k)
-				obj = v
+
+			for idx, iflag := range varg {
+				flag, ok := iflag.(string)
+				if !ok {
+					errs.add(fmt.Sprintf("%s:%d", sk, idx), fmt.Errorf("flag should be a string"))
+					continue
+				}
+				tmc.flags = append(tmc.flags, flag)
 			}
+		case "args":
+			varg, ok := v.([]interface{})
+			if !ok {
+				errs.add(sk, fmt.Errorf("arguments should be a list"))
+				continue
+			}
+
+			for idx, iarg := range varg {
+				marg, ok := iarg.(map[interface{}]interface{})
+				if !ok {
+					errs.add(fmt.Sprintf("%s:%d", sk, idx), fmt.Errorf("argument should be key/value syntax"))
+					continue
+				}
+
+				arg, err := NewTeaConfModArg(marg)
+				if err != nil {
+					errs.add(fmt.Sprintf("%s:%d", sk, idx), err)
+					continue
+				}
+				tmc.arguments = append(tmc.arguments, arg)
+			}
+		default:
+			errs.add(sk, fmt.Errorf("unknown command option"))
 		}
 	}
 
-	if failed != "" {
-		fmt.Printf("Unknown syntax on commands configuration near \"%v\" of command \"%s\"\n", failed, tmc.title)
-		fmt.Println("Value: ", obj)
-		os.Exit(1)
+	if tmc.path == "" && cmd["path"] == nil {
+		errs.add("path", fmt.Errorf("no target executable defined"))
 	}
 
-	return nil
+	// Terminal does not separate the STDERR, so there is nothing to split
+	if tmc.pty && tmc.stderr == STDERR_SPLIT {
+		errs.add("stderr", fmt.Errorf("split STDERR is not supported with pty"))
+	}
+
+	for _, tce := range errs {
+		tce.command = tmc.title
+	}
+
+	return errs.get()
+}
+
+// Parse timeout, which is either a number of seconds or a duration, such as "1m30s"
+func parseTimeout(t string) (time.Duration, error) {
+	if sec, err := strconv.Atoi(t); err == nil {
+		t = fmt.Sprintf("%ds", sec)
+	}
+
+	d, err := time.ParseDuration(t)
+	if err != nil {
+		return 0, fmt.Errorf("timeout should be a number of seconds or a duration, e.g. \"1m30s\"")
+	} else if d < 0 {
+		return 0, fmt.Errorf("timeout should not be negative")
+	}
+
+	return d, nil
 }
 
 func (tmc *TeaConfModCommand) GetTitle() string {
@@ -324,6 +498,80 @@ func (tmc *TeaConfModCommand) GetOptionLabel() string {
 	return tmc.option
 }
 
+// GetWorkingDir returns a directory, where the command runs
+func (tmc *TeaConfModCommand) GetWorkingDir() string {
+	return tmc.cwd
+}
+
+// SetWorkingDir sets a directory, where the command runs
+func (tmc *TeaConfModCommand) SetWorkingDir(cwd string) {
+	tmc.cwd = cwd
+}
+
+// GetEnv returns an additional environment of the command
+func (tmc *TeaConfModCommand) GetEnv() map[string]string {
+	return tmc.env
+}
+
+// GetTimeout returns a time after which the command is killed. Zero means no timeout.
+func (tmc *TeaConfModCommand) GetTimeout() time.Duration {
+	return tmc.timeout
+}
+
+// IsPty returns true if the command should run in a pseudo-terminal
+func (tmc *TeaConfModCommand) IsPty() bool {
+	return tmc.pty
+}
+
+// GetStderrMode returns where STDERR of the command is shown: "inline" or "split"
+func (tmc *TeaConfModCommand) GetStderrMode() string {
+	if tmc.stderr == "" {
+		return STDERR_INLINE
+	}
+	return tmc.stderr
+}
+
+// IsInteractive returns true if the command should run on the real terminal, while the UI is suspended
+func (tmc *TeaConfModCommand) IsInteractive() bool {
+	return tmc.interactive
+}
+
+// GetResult returns a presentation of the exit code, or nil if it is not declared
+func (tmc *TeaConfModCommand) GetResult(code int) *TeaConfCmdResult {
+	return tmc.results[code]
+}
+
+// GetResults returns presentations of all declared exit codes
+func (tmc *TeaConfModCommand) GetResults() map[int]*TeaConfCmdResult {
+	return tmc.results
+}
+
+// NewCommand creates an executable command in the working directory of the module command
+// and with its environment, merged over the global one. The "cmdpath" is usually the path of
+// the module command itself, but it can be also a setup command of the module.
+func (tmc *TeaConfModCommand) NewCommand(cmdpath string, args ...string) *exec.Cmd {
+	cmd := exec.Command(cmdpath, args...)
+	cmd.Dir = tmc.cwd
+	cmd.Env = append(tmc.getGlobalEnviron(), envPairs(tmc.env)...)
+
+	return cmd
+}
+
+// Environment of the process with the global one of the configuration, but without the one of the command
+func (tmc *TeaConfModCommand) getGlobalEnviron() []string {
+	return append(os.Environ(), envPairs(tmc.globalEnv)...)
+}
+
+// Environment as "key=value" pairs
+func envPairs(env map[string]string) []string {
+	pairs := []string{}
+	for k, v := range env {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	return pairs
+}
+
 // GetArguments returns module args
 func (tmc *TeaConfModCommand) GetArguments() []*TeaConfModArg {
 	return tmc.arguments
@@ -337,11 +585,13 @@ func (tmc *TeaConfModCommand) GetStaticFlags() []string {
 // TeaConfModule is a wrapper for UI to shape a correct arguments to a target executable, call it, interact with it
 // and provide results for futher processing within a chain.
 type TeaConfModule struct {
-	socketPath string
 	landing    string
 	setup      string
+	globalEnv  map[string]string
 	conditions []map[string][]string
 	commands   []*TeaConfModCommand
+	errors     []*TeaConfError
+	fileErrors []*TeaConfError
 
 	TeaConfBaseEntity
 }
@@ -350,20 +600,65 @@ func NewTeaConfModule(title string) *TeaConfModule {
 	tcm := new(TeaConfModule)
 	tcm.SetTitle(title)
 	tcm.etype = "module"
+	tcm.errors = []*TeaConfError{}
+	tcm.fileErrors = []*TeaConfError{}
 
 	return tcm
 }
 
-// SetCallbackPath sets a physical path on the disk for the Unix socket to communicate between the processes.
-func (tcf *TeaConfModule) SetCallbackPath(pt interface{}) *TeaConfModule {
-	if v, ok := pt.(string); ok {
-		tcf.socketPath = v
+// Attach errors to the module file
+func (tcf *TeaConfModule) asModuleErrors(key string, err error) []*TeaConfError {
+	tces := asTeaConfErrors(key, err)
+	for _, tce := range tces {
+		tce.file = path.Join(tcf.GetModulePath(), "init.conf")
+		tce.module = tcf.GetTitle()
 	}
+
+	return tces
+}
+
+// Add configuration errors to the module. Module with errors is still loaded, but cannot run.
+func (tcf *TeaConfModule) addError(key string, err error) *TeaConfModule {
+	tcf.errors = append(tcf.errors, tcf.asModuleErrors(key, err)...)
+	return tcf
+}
+
+// Add errors of the files, the module refers to. Such module still runs, as the files might appear
+// later, e.g. by its setup, or the module is not allowed to run by its conditions anyway.
+func (tcf *TeaConfModule) addFileError(key string, err error) *TeaConfModule {
+	tcf.fileErrors = append(tcf.fileErrors, tcf.asModuleErrors(key, err)...)
 	return tcf
 }
 
+// GetErrors returns all configuration errors of the module.
+func (tcf *TeaConfModule) GetErrors() []*TeaConfError {
+	return tcf.errors
+}
+
+// GetFileErrors returns errors of the files, those the module refers to, but are missing or cannot be run.
+func (tcf *TeaConfModule) GetFileErrors() []*TeaConfError {
+	return tcf.fileErrors
+}
+
+// SetGlobalEnv sets the global environment of the configuration to the commands of the module,
+// so their own environment is merged over it. It should be set before the commands.
+func (tcf *TeaConfModule) SetGlobalEnv(env map[string]string) *TeaConfModule {
+	tcf.globalEnv = env
+	return tcf
+}
+
+// SetSetupCommand sets the command, which loads the data of the forms. Its executable is relative to the module directory.
 func (tcf *TeaConfModule) SetSetupCommand(setup string) *TeaConfModule {
 	tcf.setup = setup
+	if setup := tcf.GetSetupCommand(); setup != "" {
+		if !strings.HasPrefix(setup, "/") {
+			setup = path.Join(tcf.GetModulePath(), setup)
+		}
+		if err := isExecutable(setup); err != nil {
+			tcf.addFileError("setup", err)
+		}
+	}
+
 	return tcf
 }
 
@@ -382,16 +677,16 @@ func (tcf *TeaConfModule) GetSetupCommandArgs() []string {
 	return []string{}
 }
 
-// SetLandingPageType is one of "logger", "progress", "list" etc.
+// SetLandingPageType is one of "logger", "progress", "list", "terminal" etc.
 func (tcf *TeaConfModule) SetLandingPageType(lp string) *TeaConfModule {
 	switch lp {
-	case "logger", "progress", "list":
+	case "logger", "progress", "list", "terminal":
 		tcf.landing = lp
 	case "":
 		tcf.landing = "logger"
 	default:
-		fmt.Printf("Error: unknown landing page ID in module \"%s\"\n", tcf.GetTitle())
-		os.Exit(1)
+		tcf.landing = "logger"
+		tcf.addError("landing", fmt.Errorf("unknown landing page type \"%s\"", lp))
 	}
 	return tcf
 }
@@ -400,38 +695,51 @@ func (tcf *TeaConfModule) GetLandingPageType() string {
 	return tcf.landing
 }
 
-// GetCallbackPath returns a physical path on the disk for the Unix socket to communicate between the processes.
-func (tcf *TeaConfModule) GetCallbackPath() string {
-	return tcf.socketPath
-}
-
 // SetCondition sets described conditions, under which module is running or not.
 func (tcf *TeaConfModule) SetCondition(cond interface{}) *TeaConfModule {
 	if cond == nil {
 		return tcf
 	}
 
-	tcf.conditions = []map[string][]string{}
+	conditions, err := parseConditions(cond)
+	if err == nil {
+		// Processor consumes the conditions, so they are checked on their own copy
+		check, _ := parseConditions(cond)
+		_, err = teaconditions.NewTeaConditionsProcessor(check)
+	}
+
+	if err != nil {
+		tcf.addError("conditions", err)
+	} else {
+		tcf.conditions = conditions
+	}
+
+	return tcf
+}
+
+// parseConditions converts raw YAML conditions into a form, which is accepted by the conditions processor.
+func parseConditions(cond interface{}) ([]map[string][]string, error) {
+	conditions := []map[string][]string{}
 	condset, ok := cond.([]interface{})
 	if !ok {
-		panic("Wrong configuration of the module: " + tcf.title)
+		return nil, fmt.Errorf("conditions should be a list")
 	}
 
 	for _, icnd := range condset {
 		cnd := map[string][]string{}
 		imcnd, cnvOk := icnd.(map[interface{}]interface{})
 		if !cnvOk {
-			panic(fmt.Sprintf("wrong condition syntax: %v", icnd))
+			return nil, fmt.Errorf("wrong condition syntax: %v", icnd)
 		}
 		for k, v := range imcnd {
 			ks, ok := k.(string)
 			if !ok {
-				panic(fmt.Sprintf("Wrong configuration of the module %s: key %v is not string", tcf.title, k))
+				return nil, fmt.Errorf("key %v is not string", k)
 			}
 			varr, ok := v.([]interface{})
 			if !ok {
 				// This is a message, so always one element in the array
-				cnd[ks] = []string{v.(string)}
+				cnd[ks] = []string{fmt.Sprintf("%v", v)}
 			} else {
 				// This is a list of clauses
 				for _, ivs := range varr {
@@ -444,10 +752,10 @@ func (tcf *TeaConfModule) SetCondition(cond interface{}) *TeaConfModule {
 				}
 			}
 		}
-		tcf.conditions = append(tcf.conditions, cnd)
+		conditions = append(conditions, cnd)
 	}
 
-	return tcf
+	return conditions, nil
 }
 
 func (tcf *TeaConfModule) GetConditions() []map[string][]string {
@@ -462,20 +770,113 @@ func (tcf *TeaConfModule) SetCommands(commands interface{}) *TeaConfModule {
 
 	cmds, ok := commands.([]interface{})
 	if !ok {
-		panic(fmt.Sprintf("Wrong configuration of commands at module %s", tcf.title))
+		return tcf.addError("commands", fmt.Errorf("commands should be a list"))
 	}
 
-	for _, cmddata := range cmds {
+	for idx, cmddata := range cmds {
+		key := fmt.Sprintf("commands:%d", idx)
 		cmd, ok := cmddata.(map[interface{}]interface{})
 		if !ok {
-			panic(fmt.Sprintf("Wrong configuration of commands at module %s", tcf.title))
+			tcf.addError(key, fmt.Errorf("command should be key/value syntax"))
+			continue
+		}
+
+		modcmd, err := NewTeaConfModCommand(cmd)
+		if err != nil {
+			tcf.addError(key, err)
+			continue
+		}
+		modcmd.globalEnv = tcf.globalEnv
+
+		// Pseudo-terminal is supported only by the landers, which can display it
+		if modcmd.IsPty() {
+			switch tcf.landing {
+			case "", "logger", "terminal":
+			default:
+				tce := newTeaConfFieldError("pty", "pty is not supported by the \"%s\" landing page", tcf.landing)
+				tce.command = modcmd.GetTitle()
+				tcf.addError(key, tce)
+				continue
+			}
+		}
+
+		// Commands are running in the module directory, unless specified
+		if modcmd.GetWorkingDir() == "" {
+			modcmd.SetWorkingDir(tcf.GetModulePath())
+		} else if !strings.HasPrefix(modcmd.GetWorkingDir(), "/") {
+			modcmd.SetWorkingDir(path.Join(tcf.GetModulePath(), modcmd.GetWorkingDir()))
 		}
-		tcf.commands = append(tcf.commands, NewTeaConfModCommand(cmd))
+
+		// Message files of the results are relative to the module directory
+		for _, res := range modcmd.GetResults() {
+			if res.GetTextFile() != "" && !strings.HasPrefix(res.GetTextFile(), "/") {
+				res.SetTextFile(path.Join(tcf.GetModulePath(), res.GetTextFile()))
+			}
+		}
+
+		if err := tcf.checkCommandFiles(modcmd); err != nil {
+			tcf.addFileError(key, err)
+		}
+		tcf.commands = append(tcf.commands, modcmd)
 	}
 
 	return tcf
 }
 
+// Check the files on the disk, which the command refers to. Paths are resolved the same way, as they are at runtime.
+func (tcf *TeaConfModule) checkCommandFiles(cmd *TeaConfModCommand) error {
+	errs := TeaConfErrors{}
+
+	cmdpath := cmd.GetCommandPath()
+	if !strings.HasPrefix(cmdpath, "/") {
+		cmdpath = path.Join(tcf.GetModulePath(), cmdpath)
+	}
+	errs.add("path", isExecutable(cmdpath))
+
+	if statfo, err := os.Stat(cmd.GetWorkingDir()); err != nil {
+		errs.add("cwd", err)
+	} else if !statfo.IsDir() {
+		errs.add("cwd", fmt.Errorf("%s is not a directory", cmd.GetWorkingDir()))
+	}
+
+	for code, res := range cmd.GetResults() {
+		if res.GetTextFile() == "" {
+			continue
+		}
+		if _, err := os.Stat(res.GetTextFile()); err != nil {
+			errs.add(fmt.Sprintf("results:%d:textfile", code), err)
+		}
+	}
+
+	// Signal actions are called from the directory of the command
+	for idx, arg := range cmd.GetArguments() {
+		for _, sig := range arg.GetSignals().GetSignals() {
+			act := arg.GetSignals().GetSignalValue(sig)
+			errs.add(fmt.Sprintf("args:%d:signals:%s", idx, sig), isExecutable(path.Join(path.Dir(cmdpath), act.GetName())))
+		}
+	}
+
+	for _, tce := range errs {
+		tce.command = cmd.GetTitle()
+	}
+
+	return errs.get()
+}
+
+// Check if the path is an executable file
+func isExecutable(pth string) error {
+	statfo, err := os.Stat(pth)
+	if err != nil {
+		return err
+	}
+
+	if statfo.IsDir() || statfo.Mode()&0111 == 0 {
+		return fmt.Errorf("%s is not an executable", pth)
+	}
+
+	return nil
+}
+
 func (tcf *TeaConfModule) GetCommands() []*TeaConfModCommand {
 	return tcf.commands
 }
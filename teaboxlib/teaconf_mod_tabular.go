@@ -45,19 +45,18 @@ func NewTeaConfTabularData(data []interface{}) *TeaConfTabularData {
 	}
 }
 
-func (tcd *TeaConfTabularData) MakeOptionsData() []*TeaConfCmdOption {
+func (tcd *TeaConfTabularData) MakeOptionsData() ([]*TeaConfCmdOption, error) {
 	options := []*TeaConfCmdOption{}
 
 	// Bail-out if there is no data
-	if tcd.data == nil {
-		return options
+	if len(tcd.data) == 0 {
+		return options, nil
 	}
 
 	// Get table header
 	header, ok := tcd.data[0].([]interface{})
 	if !ok {
-		panic("Wrong type of tabular header") // XXX: remove this
-		//return options
+		return nil, newTeaConfFieldError("0", "wrong type of tabular header: should be an array")
 	}
 
 	// Find which field is a response value and setup its attrs
@@ -69,10 +68,10 @@ func (tcd *TeaConfTabularData) MakeOptionsData() []*TeaConfCmdOption {
 	})
 
 	// Get tabular body
-	for _, rdata := range tcd.data[1:] {
+	for idx, rdata := range tcd.data[1:] {
 		row, ok := rdata.([]interface{})
 		if !ok {
-			panic("Wrong tabular data: should be an array")
+			return nil, newTeaConfFieldError(fmt.Sprintf("%d", idx+1), "wrong tabular data: should be an array")
 		}
 
 		options = append(options, &TeaConfCmdOption{
@@ -82,7 +81,7 @@ func (tcd *TeaConfTabularData) MakeOptionsData() []*TeaConfCmdOption {
 		})
 	}
 
-	return options
+	return options, nil
 }
 
 // Get header labels
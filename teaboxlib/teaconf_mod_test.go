@@ -0,0 +1,296 @@
+package teaboxlib
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/isbm/teabox/teaboxlib/teaconditions"
+)
+
+type TeaConfModTestSuite struct {
+	suite.Suite
+}
+
+func TestTeaConfModTestSuite(t *testing.T) {
+	suite.Run(t, new(TeaConfModTestSuite))
+}
+
+func (suite *TeaConfModTestSuite) TestArgMissingName() {
+	_, err := NewTeaConfModArg(map[interface{}]interface{}{
+		"type":    "text",
+		"label":   "Name",
+		"options": []interface{}{[]interface{}{"Mordor"}},
+	})
+
+	suite.NotNil(err)
+	suite.Equal("name", err.(*TeaConfError).GetKey())
+}
+
+func (suite *TeaConfModTestSuite) TestArgBadTabular() {
+	_, err := NewTeaConfModArg(map[interface{}]interface{}{
+		"type":    "tabular",
+		"name":    "--pkg",
+		"label":   "Package",
+		"options": []interface{}{"not a header"},
+	})
+
+	suite.NotNil(err)
+	suite.Equal("options:0", err.(*TeaConfError).GetKey())
+}
+
+func (suite *TeaConfModTestSuite) TestArgBadAttribute() {
+	_, err := NewTeaConfModArg(map[interface{}]interface{}{
+		"type":       "text",
+		"name":       "--name",
+		"label":      "Name",
+		"options":    []interface{}{[]interface{}{}},
+		"attributes": []interface{}{"skip-empty", "height ="},
+	})
+
+	suite.NotNil(err)
+	suite.Equal("attributes:1", err.(*TeaConfError).GetKey())
+}
+
+func (suite *TeaConfModTestSuite) TestCommandFieldPath() {
+	_, err := NewTeaConfModCommand(map[interface{}]interface{}{
+		"path":  "run.sh",
+		"title": "Run",
+		"args": []interface{}{
+			map[interface{}]interface{}{
+				"type":    "spinner",
+				"name":    "--x",
+				"label":   "X",
+				"options": []interface{}{[]interface{}{}},
+			},
+		},
+	})
+
+	suite.NotNil(err)
+	suite.Equal("args:0:type", err.(*TeaConfError).GetKey())
+	suite.Equal("Run", err.(*TeaConfError).GetCommand())
+}
+
+func (suite *TeaConfModTestSuite) TestCommandAllErrors() {
+	_, err := NewTeaConfModCommand(map[interface{}]interface{}{
+		"title":   "Run",
+		"stderr":  "both",
+		"timeout": "soon",
+		"args": []interface{}{
+			map[interface{}]interface{}{
+				"type":  "spinner",
+				"label": "X",
+			},
+		},
+	})
+
+	suite.NotNil(err)
+	keys := []string{}
+	for _, tce := range err.(TeaConfErrors) {
+		suite.Equal("Run", tce.GetCommand())
+		keys = append(keys, tce.GetKey())
+	}
+	suite.ElementsMatch([]string{"stderr", "timeout", "args:0:type", "args:0:options", "args:0:name", "path"}, keys)
+}
+
+func (suite *TeaConfModTestSuite) TestBrokenModuleIsLoaded() {
+	root, err := os.MkdirTemp("", "teabox-conf")
+	suite.Nil(err)
+	defer os.RemoveAll(root)
+
+	suite.Nil(os.WriteFile(path.Join(root, "init.conf"), []byte("title: Test\n"), 0644))
+	suite.Nil(os.MkdirAll(path.Join(root, "broken"), 0755))
+	suite.Nil(os.WriteFile(path.Join(root, "broken", "init.conf"), []byte(`
+title: Broken
+landing: fancy
+commands:
+  - title: No Path
+`), 0644))
+
+	tc := &TeaConf{contentPath: root, initConfPath: path.Join(root, "init.conf")}
+	suite.Nil(tc.InitConfig())
+	suite.Len(tc.GetErrors(), 2)
+
+	mod, ok := tc.GetModuleStructure()[0].(*TeaConfModule)
+	suite.True(ok)
+	suite.Equal("Broken", mod.GetTitle())
+	suite.Equal("logger", mod.GetLandingPageType())
+	for _, e := range mod.GetErrors() {
+		suite.Equal(path.Join(root, "broken", "init.conf"), e.GetFile())
+		suite.Equal("Broken", e.GetModule())
+	}
+}
+
+func (suite *TeaConfModTestSuite) TestLandingPageType() {
+	for _, lp := range []string{"logger", "progress", "list", "terminal"} {
+		mod := NewTeaConfModule("Landing").SetLandingPageType(lp)
+		suite.Equal(lp, mod.GetLandingPageType())
+		suite.Len(mod.GetErrors(), 0)
+	}
+}
+
+func (suite *TeaConfModTestSuite) TestConditionsAreKept() {
+	mod := NewTeaConfModule("Conditional").SetCondition([]interface{}{
+		map[interface{}]interface{}{
+			"absent":  []interface{}{"/dev/darth-vader"},
+			"message": "Darth Vader is around!",
+		},
+	})
+
+	suite.Empty(mod.GetErrors())
+	_, err := teaconditions.NewTeaConditionsProcessor(mod.GetConditions())
+	suite.Nil(err)
+}
+
+func (suite *TeaConfModTestSuite) TestCommandRuntimeOptions() {
+	mod := NewTeaConfModule("Runtime")
+	mod.SetModulePath("/opt/modules/runtime")
+	mod.SetCommands([]interface{}{
+		map[interface{}]interface{}{
+			"path":    "run.sh",
+			"timeout": 90,
+			"env":     map[interface{}]interface{}{"LANG": "C", "DEBUG": 1},
+			"stderr":  "split",
+		},
+		map[interface{}]interface{}{
+			"path":        "finish.sh",
+			"cwd":         "data",
+			"timeout":     "1m30s",
+			"interactive": true,
+		},
+	})
+
+	suite.Empty(mod.GetErrors())
+	suite.Len(mod.GetCommands(), 2)
+
+	run := mod.GetCommands()[0]
+	suite.Equal("/opt/modules/runtime", run.GetWorkingDir())
+	suite.Equal(90*time.Second, run.GetTimeout())
+	suite.Equal(map[string]string{"LANG": "C", "DEBUG": "1"}, run.GetEnv())
+	suite.False(run.IsPty())
+	suite.False(run.IsInteractive())
+	suite.Equal(STDERR_SPLIT, run.GetStderrMode())
+
+	cmd := run.NewCommand("/bin/true")
+	suite.Equal("/opt/modules/runtime", cmd.Dir)
+	suite.Contains(cmd.Env, "LANG=C")
+
+	finish := mod.GetCommands()[1]
+	suite.Equal("/opt/modules/runtime/data", finish.GetWorkingDir())
+	suite.Equal(90*time.Second, finish.GetTimeout())
+	suite.False(finish.IsPty())
+	suite.True(finish.IsInteractive())
+	suite.Equal(STDERR_INLINE, finish.GetStderrMode())
+}
+
+func (suite *TeaConfModTestSuite) TestCommandBadTimeout() {
+	_, err := NewTeaConfModCommand(map[interface{}]interface{}{
+		"path":    "run.sh",
+		"timeout": "forever",
+	})
+
+	suite.NotNil(err)
+	suite.Equal("timeout", err.(*TeaConfError).GetKey())
+}
+
+func (suite *TeaConfModTestSuite) TestCommandBadStderr() {
+	_, err := NewTeaConfModCommand(map[interface{}]interface{}{
+		"path":   "run.sh",
+		"stderr": "nowhere",
+	})
+
+	suite.NotNil(err)
+	suite.Equal("stderr", err.(*TeaConfError).GetKey())
+}
+
+func (suite *TeaConfModTestSuite) TestCommandResults() {
+	mod := NewTeaConfModule("Results")
+	mod.SetModulePath("/opt/modules/results")
+	mod.SetCommands([]interface{}{
+		map[interface{}]interface{}{
+			"path": "run.sh",
+			"results": map[interface{}]interface{}{
+				0: map[interface{}]interface{}{"message": "All done"},
+				3: map[interface{}]interface{}{
+					"title":    "Nothing to do",
+					"textfile": "nothing.txt",
+					"severity": "warning",
+				},
+			},
+		},
+	})
+
+	suite.Empty(mod.GetErrors())
+
+	run := mod.GetCommands()[0]
+	suite.Nil(run.GetResult(1))
+	suite.Equal(RESULT_INFO, run.GetResult(0).GetSeverity())
+	suite.Equal("All done", run.GetResult(0).GetMessage())
+	suite.Equal("Nothing to do", run.GetResult(3).GetTitle())
+	suite.Equal(RESULT_WARNING, run.GetResult(3).GetSeverity())
+	suite.Equal("/opt/modules/results/nothing.txt", run.GetResult(3).GetTextFile())
+}
+
+func (suite *TeaConfModTestSuite) TestCommandBadResults() {
+	_, err := NewTeaConfModCommand(map[interface{}]interface{}{
+		"path":    "run.sh",
+		"results": map[interface{}]interface{}{2: map[interface{}]interface{}{"message": "Oops", "severity": "fatal"}},
+	})
+	suite.NotNil(err)
+	suite.Equal("results:2:severity", err.(*TeaConfError).GetKey())
+
+	_, err = NewTeaConfModCommand(map[interface{}]interface{}{
+		"path":    "run.sh",
+		"results": map[interface{}]interface{}{"two": map[interface{}]interface{}{"message": "Oops"}},
+	})
+	suite.NotNil(err)
+	suite.Equal("results:two", err.(*TeaConfError).GetKey())
+}
+
+func (suite *TeaConfModTestSuite) TestCommandGlobalEnv() {
+	mod := NewTeaConfModule("Env").SetGlobalEnv(map[string]string{"TEABOX_TEST_GLOBAL": "yes", "TEABOX_TEST_MODE": "global"})
+	mod.SetCommands([]interface{}{
+		map[interface{}]interface{}{
+			"path": "run.sh",
+			"env":  map[interface{}]interface{}{"TEABOX_TEST_MODE": "command"},
+		},
+	})
+	suite.Empty(mod.GetErrors())
+
+	out, err := mod.GetCommands()[0].NewCommand("/bin/sh", "-c", "echo $TEABOX_TEST_GLOBAL $TEABOX_TEST_MODE").Output()
+	suite.Nil(err)
+	suite.Equal("yes command\n", string(out))
+
+	// Environment of Teabox itself stays as is
+	_, exists := os.LookupEnv("TEABOX_TEST_GLOBAL")
+	suite.False(exists)
+}
+
+func (suite *TeaConfModTestSuite) TestCommandPtyStderr() {
+	mod := NewTeaConfModule("Pty")
+	mod.SetCommands([]interface{}{
+		map[interface{}]interface{}{"path": "run.sh", "pty": true, "stderr": "inline"},
+		map[interface{}]interface{}{"path": "run.sh", "pty": true, "stderr": "split"},
+	})
+
+	suite.Len(mod.GetCommands(), 1)
+	suite.Len(mod.GetErrors(), 1)
+	suite.Equal("commands:1:stderr", mod.GetErrors()[0].GetKey())
+}
+
+func (suite *TeaConfModTestSuite) TestCommandPtyLanding() {
+	for _, lp := range []string{"logger", "terminal", "progress", "list"} {
+		mod := NewTeaConfModule("Pty").SetLandingPageType(lp)
+		mod.SetCommands([]interface{}{map[interface{}]interface{}{"path": "run.sh", "pty": true}})
+
+		if lp == "logger" || lp == "terminal" {
+			suite.Empty(mod.GetErrors())
+			suite.Len(mod.GetCommands(), 1)
+		} else {
+			suite.Len(mod.GetErrors(), 1)
+			suite.Equal("commands:0:pty", mod.GetErrors()[0].GetKey())
+		}
+	}
+}
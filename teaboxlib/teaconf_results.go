@@ -0,0 +1,127 @@
+package teaboxlib
+
+import (
+	"fmt"
+	"os"
+)
+
+// Severities of a command result, those are picking the popup to show it
+const (
+	RESULT_INFO    = "info"
+	RESULT_WARNING = "warning"
+	RESULT_ALERT   = "alert"
+)
+
+/*
+TeaConfCmdResult describes how an exit code of a module command is presented to the user.
+Example in the command configuration:
+
+	results:
+	  3:
+	    title: Nothing to do
+	    message: Everything is already up to date
+	    severity: info
+*/
+type TeaConfCmdResult struct {
+	code     int
+	title    string
+	message  string
+	textfile string // Message is taken from this file instead, if set
+	severity string
+}
+
+// NewTeaConfCmdResult constructor. Severity is "info" for zero exit code and "alert" otherwise, unless specified.
+func NewTeaConfCmdResult(code int, data interface{}) (*TeaConfCmdResult, error) {
+	tcr := &TeaConfCmdResult{
+		code:     code,
+		severity: RESULT_ALERT,
+	}
+	if code == 0 {
+		tcr.severity = RESULT_INFO
+	}
+
+	if err := tcr.parse(data); err != nil {
+		return nil, err
+	}
+
+	return tcr, nil
+}
+
+func (tcr *TeaConfCmdResult) parse(data interface{}) error {
+	result, ok := data.(map[interface{}]interface{})
+	if !ok {
+		return newTeaConfFieldError("", "result should be key/value syntax")
+	}
+
+	errs := TeaConfErrors{}
+	for k, v := range result {
+		sv, ok := v.(string)
+		if !ok || sv == "" {
+			errs.add(fmt.Sprintf("%v", k), fmt.Errorf("should be a non-empty string"))
+			continue
+		}
+
+		switch k {
+		case "title":
+			tcr.title = sv
+		case "message":
+			tcr.message = sv
+		case "textfile":
+			tcr.textfile = sv
+		case "severity":
+			switch sv {
+			case RESULT_INFO, RESULT_WARNING, RESULT_ALERT:
+				tcr.severity = sv
+			default:
+				errs.add("severity", fmt.Errorf("unknown severity \"%s\", should be info, warning or alert", sv))
+			}
+		default:
+			errs.add(fmt.Sprintf("%v", k), fmt.Errorf("unknown result option"))
+		}
+	}
+
+	if len(errs) == 0 && tcr.message == "" && tcr.textfile == "" {
+		errs.add("message", fmt.Errorf("result has no message defined"))
+	}
+
+	return errs.get()
+}
+
+// GetCode returns the exit code of the result
+func (tcr *TeaConfCmdResult) GetCode() int {
+	return tcr.code
+}
+
+// GetTitle returns a title of the popup. Can be empty, if not defined.
+func (tcr *TeaConfCmdResult) GetTitle() string {
+	return tcr.title
+}
+
+// GetSeverity returns a severity of the result: info, warning or alert
+func (tcr *TeaConfCmdResult) GetSeverity() string {
+	return tcr.severity
+}
+
+// GetTextFile returns a path to the message file, if any
+func (tcr *TeaConfCmdResult) GetTextFile() string {
+	return tcr.textfile
+}
+
+// SetTextFile sets a path to the message file
+func (tcr *TeaConfCmdResult) SetTextFile(textfile string) {
+	tcr.textfile = textfile
+}
+
+// GetMessage returns a message of the result, reading it from the message file if needed
+func (tcr *TeaConfCmdResult) GetMessage() string {
+	if tcr.textfile == "" {
+		return tcr.message
+	}
+
+	data, err := os.ReadFile(tcr.textfile)
+	if err != nil {
+		return "<failed to read message file at " + tcr.textfile + ">"
+	}
+
+	return string(data)
+}
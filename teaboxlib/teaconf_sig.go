@@ -72,15 +72,25 @@ func NewTeaConfArgSignals(data map[interface{}]interface{}) (*TeaConfArgSignals,
 		data = map[interface{}]interface{}{}
 	}
 
+	errs := TeaConfErrors{}
 	for k, v := range data {
-		action, err := NewTeaConfArgSignalAction(fmt.Sprintf("%v", v))
+		sv, _ := v.(string)
+		action, err := NewTeaConfArgSignalAction(sv)
+		if err == nil && action.GetName() == "" {
+			err = fmt.Errorf("no signal action defined")
+		}
+
 		if err != nil {
-			return nil, err
+			errs.add(fmt.Sprintf("%v", k), err)
 		} else {
 			tcsig.SetSignal(fmt.Sprintf("%v", k), action)
 		}
 	}
 
+	if err := errs.get(); err != nil {
+		return nil, err
+	}
+
 	return tcsig, nil
 }
 
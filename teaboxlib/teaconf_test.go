@@ -0,0 +1,185 @@
+package teaboxlib
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TeaConfTestSuite struct {
+	root string
+	suite.Suite
+}
+
+func TestTeaConfTestSuite(t *testing.T) {
+	suite.Run(t, new(TeaConfTestSuite))
+}
+
+func (suite *TeaConfTestSuite) SetupTest() {
+	var err error
+	suite.root, err = os.MkdirTemp("", "teabox-conf")
+	suite.Nil(err)
+	suite.Nil(os.WriteFile(path.Join(suite.root, "init.conf"), []byte("title: Test\n"), 0644))
+}
+
+func (suite *TeaConfTestSuite) TearDownTest() {
+	os.RemoveAll(suite.root)
+}
+
+func (suite *TeaConfTestSuite) addModule(name, conf string) {
+	suite.Nil(os.MkdirAll(path.Join(suite.root, name), 0755))
+	suite.Nil(os.WriteFile(path.Join(suite.root, name, "init.conf"), []byte(conf), 0644))
+}
+
+func (suite *TeaConfTestSuite) load() []*TeaConfError {
+	tc := &TeaConf{contentPath: suite.root, initConfPath: path.Join(suite.root, "init.conf")}
+	suite.Nil(tc.InitConfig())
+	return tc.GetErrors()
+}
+
+func (suite *TeaConfTestSuite) keys(errs []*TeaConfError) []string {
+	keys := []string{}
+	for _, e := range errs {
+		keys = append(keys, e.GetKey())
+	}
+	return keys
+}
+
+func (suite *TeaConfTestSuite) TestValidModule() {
+	suite.addModule("good", `
+title: Good
+commands:
+  - path: /bin/sh
+    title: Run
+    args:
+      - type: text
+        name: --name
+        label: Name
+        options:
+          - ["Mordor"]
+`)
+	suite.Empty(suite.load())
+}
+
+func (suite *TeaConfTestSuite) TestReportsAllProblems() {
+	suite.addModule("bad", `
+title: Bad
+landing: fancy
+commands:
+  - path: /bin/sh
+    title: Run
+    shell: bash
+    args:
+      - type: spinner
+        options:
+          - ["Mordor"]
+  - path: /bin/sh
+    title: ""
+`)
+	errs := suite.load()
+	for _, e := range errs {
+		suite.Equal("Bad", e.GetModule())
+		suite.Equal(path.Join(suite.root, "bad", "init.conf"), e.GetFile())
+	}
+
+	suite.ElementsMatch([]string{
+		"landing",
+		"commands:0:shell",
+		"commands:0:args:0:type",
+		"commands:0:args:0:label",
+		"commands:0:args:0:name",
+		"commands:1:title",
+	}, suite.keys(errs))
+}
+
+func (suite *TeaConfTestSuite) TestMissingFiles() {
+	suite.addModule("files", `
+title: Files
+setup: missing-setup.sh --all
+commands:
+  - path: missing.sh
+    title: Run
+    cwd: data
+    results:
+      4:
+        textfile: missing.txt
+    args:
+      - type: text
+        name: --name
+        label: Name
+        options:
+          - ["Mordor"]
+        signals:
+          changed: does-not-exist.sh
+`)
+	errs := suite.load()
+	for _, e := range errs {
+		if e.GetKey() != "setup" {
+			suite.Equal("Run", e.GetCommand())
+		}
+	}
+
+	suite.ElementsMatch([]string{
+		"setup",
+		"commands:0:path",
+		"commands:0:cwd",
+		"commands:0:results:4:textfile",
+		"commands:0:args:0:signals:changed",
+	}, suite.keys(errs))
+}
+
+func (suite *TeaConfTestSuite) TestBrokenYAML() {
+	suite.addModule("broken", "title: [Broken\n")
+	errs := suite.load()
+	suite.Len(errs, 1)
+	suite.Equal("", errs[0].GetKey())
+}
+
+func (suite *TeaConfTestSuite) TestMissingTitle() {
+	suite.addModule("untitled", "landing: logger\n")
+	errs := suite.load()
+	suite.Len(errs, 1)
+	suite.Equal("title", errs[0].GetKey())
+	suite.Equal(path.Join(suite.root, "untitled", "init.conf"), errs[0].GetFile())
+}
+
+func (suite *TeaConfTestSuite) TestResults() {
+	suite.addModule("results", `
+title: Results
+commands:
+  - path: /bin/sh
+    title: Run
+    results:
+      0:
+        message: Done
+      3:
+        message: Nothing to do
+        severity: fatal
+      five:
+        message: Five
+`)
+	suite.ElementsMatch([]string{
+		"commands:0:results:3:severity",
+		"commands:0:results:five",
+	}, suite.keys(suite.load()))
+}
+
+func (suite *TeaConfTestSuite) TestMissingFilesDoNotBreakModule() {
+	suite.addModule("files", `
+title: Files
+commands:
+  - path: missing.sh
+    title: Run
+`)
+	tc := &TeaConf{contentPath: suite.root, initConfPath: path.Join(suite.root, "init.conf")}
+	suite.Nil(tc.InitConfig())
+	suite.Len(tc.GetErrors(), 1)
+
+	mod, ok := tc.GetModuleStructure()[0].(*TeaConfModule)
+	suite.True(ok)
+	suite.Empty(mod.GetErrors())
+	suite.Len(mod.GetFileErrors(), 1)
+	suite.Len(mod.GetCommands(), 1)
+}
@@ -22,3 +22,11 @@ var EGAColorBrightRed = tcell.NewHexColor(0xFF5555).TrueColor()
 var EGAColorBrightMagenta = tcell.NewHexColor(0xFF55FF).TrueColor()
 var EGAColorBrightYellow = tcell.NewHexColor(0xFFFF55).TrueColor()
 var EGAColorBrightWhite = tcell.NewHexColor(0xFFFFFF).TrueColor()
+
+// EGAPalette maps the 16 ANSI colour indexes of the terminal output to the EGA colors
+var EGAPalette = [16]tcell.Color{
+	EGAColorBlack, EGAColorRed, EGAColorGreen, EGAColorYellow,
+	EGAColorBlue, EGAColorMagenta, EGAColorCyan, EGAColorWhite,
+	EGAColorBrightBlack, EGAColorBrightRed, EGAColorBrightGreen, EGAColorBrightYellow,
+	EGAColorBrightBlue, EGAColorBrightMagenta, EGAColorBrightCyan, EGAColorBrightWhite,
+}
@@ -1,6 +1,8 @@
 package teaboxlib
 
 import (
+	"time"
+
 	"github.com/gdamore/tcell/v2"
 )
 
@@ -12,9 +14,12 @@ var MAIN_MENU_WIDTH int = 30
 // Labels
 var LABEL_BACK = "◀ Back"
 var LABEL_EXIT = "Exit ▶"
+var LABEL_SHELL = "Shell ▶"
+var LABEL_HISTORY = "History ▶"
 var LABEL_SEP = "─"
 var LABEL_MORE = "…"
 var LABEL_TABULAR_SELECTED = " ◆ "
+var LABEL_BROKEN = " ✘"
 
 // Colors theme
 var WORKSPACE_BACKGROUND = EGAColorGreen
@@ -38,6 +43,20 @@ var FORM_FIELD_TEXT = EGAColorBlack
 var FORM_FIELD_BACKGROUND = EGAColorLightGray
 var FORM_FIELD_BACKGROUND_DARKER = EGAColorDarkGray
 var FORM_FIELD_BACKGROUND_FOCUSED = EGAColorBrightWhite
+var FORM_FIELD_TEXT_DISABLED = EGAColorDarkGray
 
 // Logs
 var LOG_FILENAME = "/var/log/teabox.log"
+
+// Time to wait after SIGTERM for a cancelled module, before it is killed
+var CANCEL_GRACE_PERIOD = 5 * time.Second
+
+// Shell, started from the "Shell" menu entry. Empty means there is no such entry.
+var SYSTEM_SHELL = ""
+
+// Lines of the output, kept by the logger. Older lines are dropped. Zero means no limit.
+var LOGGER_MAX_LINES = 10000
+
+// File of the job history, and how many past jobs it keeps. Zero means there is no history.
+var HISTORY_FILENAME = defaultHistoryFilename()
+var HISTORY_SIZE = 100
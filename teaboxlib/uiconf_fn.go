@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 )
@@ -21,7 +22,7 @@ func NewUiConfig() *UiConfig {
 // Setup configuration
 func (uic *UiConfig) Setup(conf *TeaConf) *UiConfig {
 	uic.tc = conf
-	return uic.setLabels().setWorkspace().setMenu().setForms().setCommon().setLogFilename()
+	return uic.setLabels().setWorkspace().setMenu().setForms().setCommon().setLogFilename().setCancelGracePeriod().setShell().setLoggerMaxLines().setHistory()
 }
 
 func (uic *UiConfig) setLogFilename() *UiConfig {
@@ -34,6 +35,46 @@ func (uic *UiConfig) setLogFilename() *UiConfig {
 	return uic
 }
 
+func (uic *UiConfig) setCancelGracePeriod() *UiConfig {
+	s := uic.tc.GetRootConfig().Find("ui:system")
+	p, e := s.Int("cancel-grace-period", "")
+	if e == nil && p > 0 {
+		CANCEL_GRACE_PERIOD = time.Duration(p) * time.Second
+	}
+
+	return uic
+}
+
+func (uic *UiConfig) setShell() *UiConfig {
+	s := uic.tc.GetRootConfig().Find("ui:system")
+	SYSTEM_SHELL = s.String("shell", "")
+
+	return uic
+}
+
+func (uic *UiConfig) setLoggerMaxLines() *UiConfig {
+	s := uic.tc.GetRootConfig().Find("ui:system")
+	n, e := s.Int("logger-max-lines", "")
+	if e == nil && n >= 0 {
+		LOGGER_MAX_LINES = n
+	}
+
+	return uic
+}
+
+func (uic *UiConfig) setHistory() *UiConfig {
+	s := uic.tc.GetRootConfig().Find("ui:system")
+	if f := s.String("history-filename", ""); f != "" {
+		HISTORY_FILENAME = f
+	}
+	n, e := s.Int("history-size", "")
+	if e == nil && n >= 0 {
+		HISTORY_SIZE = n
+	}
+
+	return uic
+}
+
 func (uic *UiConfig) setCommon() *UiConfig {
 	s := uic.tc.GetRootConfig().Find("ui:widgets")
 	w, e := s.Int("menu-width", "")
@@ -47,7 +88,7 @@ func (uic *UiConfig) setCommon() *UiConfig {
 // Set labels
 func (uic *UiConfig) setLabels() *UiConfig {
 	s := uic.tc.GetRootConfig().Find("ui:widgets")
-	for _, k := range []string{"label-back", "label-exit", "label-sep", "label-more", "label-tabular-selected"} {
+	for _, k := range []string{"label-back", "label-exit", "label-sep", "label-more", "label-tabular-selected", "label-broken", "label-shell", "label-history"} {
 		l := s.String(k, "")
 		if l == "" {
 			continue
@@ -64,6 +105,12 @@ func (uic *UiConfig) setLabels() *UiConfig {
 			LABEL_MORE = l
 		case "label-tabular-selected":
 			LABEL_TABULAR_SELECTED = l
+		case "label-broken":
+			LABEL_BROKEN = l
+		case "label-shell":
+			LABEL_SHELL = l
+		case "label-history":
+			LABEL_HISTORY = l
 		}
 	}
 
@@ -135,6 +182,7 @@ func (uic *UiConfig) setForms() *UiConfig {
 		"form-field-background-focused",
 		"form-field-background",
 		"form-field-background-darker",
+		"form-field-foreground-disabled",
 	} {
 		if strings.ToLower(s.String(k, "")) != "default" && s.String(k, "") != "" {
 			c := uic.getColor(s.Raw()[k])
@@ -163,6 +211,8 @@ func (uic *UiConfig) setForms() *UiConfig {
 				FORM_FIELD_BACKGROUND = *c
 			case "form-field-background-darker":
 				FORM_FIELD_BACKGROUND_DARKER = *c
+			case "form-field-foreground-disabled":
+				FORM_FIELD_TEXT_DISABLED = *c
 			}
 		}
 	}